@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"log"
+	"msn/internal/config"
+	"msn/internal/infra/database/pg"
+	lockoutRepository "msn/internal/infra/database/pg/repositories/lockout"
+	roleRepository "msn/internal/infra/database/pg/repositories/role"
+	userRepository "msn/internal/infra/database/pg/repositories/user"
+	"msn/internal/infra/jwt"
+	"msn/internal/modules/lockout"
+	"msn/internal/modules/user"
+	"msn/pkg/utils/crypto"
+	"os"
+	"path/filepath"
+)
+
+const usage = `Usage: admin <command> [arguments]
+
+Commands:
+  unlock <email>                  clear failed login attempts and lockout for an account
+  unlock-ip <ip>                  clear failed login attempts and lockout for an IP address
+  role <email> <role>             assign a role to a user, e.g. to create the first admin
+  keys generate <access|refresh|mfa> [RS256|ES256|EdDSA]
+                                  create a signing key in JWT_KEY_DIR and make it active (default RS256)
+  keys retire <access|refresh|mfa>
+                                  remove keys that stopped signing more than the refresh TTL ago`
+
+func main() {
+	if len(os.Args) < 3 {
+		log.Fatal(usage)
+	}
+
+	cfg := config.GetConfig()
+	ctx := context.Background()
+
+	switch cmd := os.Args[1]; cmd {
+	case "unlock":
+		if err := newLockoutService(cfg).Unlock(ctx, os.Args[2]); err != nil {
+			log.Fatalf("Unlock failed: %v", err)
+		}
+		log.Printf("Account %s unlocked.", os.Args[2])
+	case "unlock-ip":
+		if err := newLockoutService(cfg).UnlockIP(ctx, os.Args[2]); err != nil {
+			log.Fatalf("Unlock failed: %v", err)
+		}
+		log.Printf("IP %s unlocked.", os.Args[2])
+	case "role":
+		if len(os.Args) < 4 {
+			log.Fatal(usage)
+		}
+		assignRole(ctx, cfg, os.Args[2], os.Args[3])
+	case "keys":
+		if len(os.Args) < 4 {
+			log.Fatal(usage)
+		}
+		algorithm := crypto.AlgorithmRS256
+		if len(os.Args) > 4 {
+			algorithm = os.Args[4]
+		}
+		runKeys(cfg, os.Args[2], os.Args[3], algorithm)
+	default:
+		log.Fatalf("Unknown command %q.\n%s", cmd, usage)
+	}
+}
+
+func newLockoutService(cfg *config.Config) lockout.Service {
+	pgConn, err := pg.NewPostgresConnection(cfg.PostgresDSN)
+	if err != nil {
+		log.Fatalf("Failed to connect to the database: %v", err)
+	}
+
+	if cfg.LockoutStore != "postgres" {
+		log.Println("LOCKOUT_STORE is not postgres; running instances keep their own counters.")
+	}
+
+	return lockout.NewService(lockout.ServiceConfig{
+		Store:         lockoutRepository.NewRepo(pgConn.DB()),
+		AccountPolicy: lockout.DefaultAccountPolicy,
+		IPPolicy:      lockout.DefaultIPPolicy,
+	})
+}
+
+func assignRole(ctx context.Context, cfg *config.Config, email, roleName string) {
+	pgConn, err := pg.NewPostgresConnection(cfg.PostgresDSN)
+	if err != nil {
+		log.Fatalf("Failed to connect to the database: %v", err)
+	}
+	userRepo := userRepository.NewRepo(pgConn.DB())
+
+	u, err := userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		log.Fatalf("Failed to load user: %v", err)
+	}
+	if u == nil {
+		log.Fatalf("User %s not found.", email)
+	}
+
+	userService := user.NewService(user.ServiceConfig{
+		UserRepo: userRepo,
+		RoleRepo: roleRepository.NewRepo(pgConn.DB()),
+	})
+	if _, err := userService.AssignRole(ctx, u.ID(), roleName); err != nil {
+		log.Fatalf("Role assignment failed: %v", err)
+	}
+	log.Printf("User %s is now %s; the change applies on their next token refresh.", email, roleName)
+}
+
+// runKeys manages the key directory. Instances read it on startup, so they
+// must be restarted to pick up a new active key.
+func runKeys(cfg *config.Config, action, purpose, algorithm string) {
+	if cfg.JWTKeyDir == "" {
+		log.Fatal("JWT_KEY_DIR must be set to manage signing keys.")
+	}
+	if purpose != "access" && purpose != "refresh" && purpose != "mfa" {
+		log.Fatalf("Unknown key purpose %q, use access, refresh or mfa.", purpose)
+	}
+	dir := filepath.Join(cfg.JWTKeyDir, purpose)
+
+	switch action {
+	case "generate":
+		kid, err := jwt.GenerateKeyInDir(dir, algorithm)
+		if err != nil {
+			log.Fatalf("Key generation failed: %v", err)
+		}
+		log.Printf("Generated %s %s key %s; it is now active.", algorithm, purpose, kid)
+	case "retire":
+		retired, err := jwt.RetireKeysInDir(dir, jwt.RefreshTokenDuration)
+		if err != nil {
+			log.Fatalf("Key retirement failed: %v", err)
+		}
+		if len(retired) == 0 {
+			log.Println("No keys to retire.")
+			return
+		}
+		for _, kid := range retired {
+			log.Printf("Retired %s key %s.", purpose, kid)
+		}
+	default:
+		log.Fatalf("Unknown keys action %q.\n%s", action, usage)
+	}
+}
@@ -8,27 +8,50 @@ import (
 	"msn/internal/config"
 	"msn/internal/infra/database/pg"
 	categoryRepository "msn/internal/infra/database/pg/repositories/category"
+	dataexportRepository "msn/internal/infra/database/pg/repositories/dataexport"
+	lockoutRepository "msn/internal/infra/database/pg/repositories/lockout"
+	mfaRepository "msn/internal/infra/database/pg/repositories/mfa"
+	ratelimitRepository "msn/internal/infra/database/pg/repositories/ratelimit"
+	revocationRepository "msn/internal/infra/database/pg/repositories/revocation"
 	roleRepository "msn/internal/infra/database/pg/repositories/role"
 	sessionRepository "msn/internal/infra/database/pg/repositories/session"
 	userRepository "msn/internal/infra/database/pg/repositories/user"
+	usertokenRepository "msn/internal/infra/database/pg/repositories/usertoken"
+	adminHandler "msn/internal/infra/http/handlers/admin"
 	authHandler "msn/internal/infra/http/handlers/auth"
 	categoryhandler "msn/internal/infra/http/handlers/category"
 	userHandler "msn/internal/infra/http/handlers/user"
+	wellknownHandler "msn/internal/infra/http/handlers/wellknown"
 	"msn/internal/infra/http/middlewares"
 	"msn/internal/infra/http/server"
 	"msn/internal/infra/jwt"
 	"msn/internal/infra/logging"
+	"msn/internal/infra/mailer"
+	"msn/internal/infra/memory"
+	"msn/internal/infra/scheduler"
 	"msn/internal/infra/storage"
 	"msn/internal/modules/auth"
 	"msn/internal/modules/category"
+	"msn/internal/modules/dataexport"
+	"msn/internal/modules/lockout"
+	"msn/internal/modules/mfa"
+	"msn/internal/modules/ratelimit"
+	"msn/internal/modules/revocation"
 	"msn/internal/modules/session"
 	"msn/internal/modules/user"
+	"msn/pkg/utils/httputils"
+	"msn/pkg/utils/locale"
 	"net/http"
+	"net/netip"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi"
 	"github.com/go-chi/cors"
+	"github.com/jmoiron/sqlx"
 )
 
 func main() {
@@ -45,12 +68,14 @@ func main() {
 	ctx := context.Background()
 	router := chi.NewRouter()
 
+	router.Use(middlewares.RealIP(newTrustedProxies(cfg)))
 	router.Use(middlewares.Logging)
 
 	router.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"http://localhost:3000"},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "If-None-Match"},
+		ExposedHeaders:   []string{"RateLimit-Policy", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "Retry-After", "ETag"},
 		AllowCredentials: true,
 		MaxAge:           300,
 	}))
@@ -68,31 +93,115 @@ func main() {
 	categoryRepo := categoryRepository.NewRepo(pgConn.DB())
 	sessionRepo := sessionRepository.NewRepo(pgConn.DB())
 	roleRepo := roleRepository.NewRepo(pgConn.DB())
+	userTokenRepo := usertokenRepository.NewRepo(pgConn.DB())
+	mfaRepo := mfaRepository.NewRepo(pgConn.DB())
 
-	tokenProvider := jwt.NewProvider(cfg.JWTAccessKey, cfg.JWTRefreshKey)
+	var lockoutStore lockout.Store = memory.NewLockoutStore()
+	if cfg.LockoutStore == "postgres" {
+		lockoutStore = lockoutRepository.NewRepo(pgConn.DB())
+	}
+
+	var rateLimitStore ratelimit.Store = memory.NewRateLimitStore()
+	if cfg.RateLimitStore == "postgres" {
+		rateLimitStore = ratelimitRepository.NewRepo(pgConn.DB())
+	}
+	rateLimits := middlewares.RateLimits{
+		middlewares.RateLimitGroupAuth:     newRateLimiter(middlewares.RateLimitGroupAuth, cfg.RateLimitAuth, rateLimitStore, middlewares.KeyByIP),
+		middlewares.RateLimitGroupRegister: newRateLimiter(middlewares.RateLimitGroupRegister, cfg.RateLimitRegister, rateLimitStore, middlewares.KeyByIP),
+		middlewares.RateLimitGroupPublic:   newRateLimiter(middlewares.RateLimitGroupPublic, cfg.RateLimitPublic, rateLimitStore, middlewares.KeyByIP),
+		middlewares.RateLimitGroupUser:     newRateLimiter(middlewares.RateLimitGroupUser, cfg.RateLimitUser, rateLimitStore, middlewares.KeyByUser),
+	}
+
+	accessKeys, refreshKeys, mfaKeys, err := loadKeyrings(cfg)
+	if err != nil {
+		slog.Error("failed to load signing keys", "error", err)
+		panic(err)
+	}
+	tokenProvider := jwt.NewProvider(accessKeys, refreshKeys, mfaKeys)
+	mailClient, err := mailer.New(mailer.Config{
+		Driver:       cfg.MailDriver,
+		From:         cfg.MailFrom,
+		SMTPHost:     cfg.SMTPHost,
+		SMTPPort:     cfg.SMTPPort,
+		SMTPUsername: cfg.SMTPUsername,
+		SMTPPassword: cfg.SMTPPassword,
+		OutboxDir:    cfg.MailOutboxDir,
+	})
+	if err != nil {
+		slog.Error("failed to create mailer", "error", err)
+		panic(err)
+	}
 
 	userService := user.NewService(user.ServiceConfig{
 		UserRepo:      userRepo,
+		UserTokenRepo: userTokenRepo,
 		CategoryRepo:  categoryRepo,
 		RoleRepo:      roleRepo,
 		StorageClient: storageClient,
+		Mailer:        mailClient,
+		DeletionGrace: parseInterval("ACCOUNT_DELETION_GRACE", cfg.AccountDeletionGrace, user.DefaultDeletionGrace),
 	})
+	revocations := newRevocations(cfg, pgConn.DB(), sessionRepo)
 	sessionService := session.NewService(session.ServiceConfig{
 		SessionRepo: sessionRepo,
 		UserService: userService,
+		Revocations: revocations,
+	})
+	mfaService := mfa.NewService(mfa.ServiceConfig{
+		MFARepo:  mfaRepo,
+		UserRepo: userRepo,
+		Issuer:   cfg.AppName,
+	})
+	lockoutService := lockout.NewService(lockout.ServiceConfig{
+		Store:         lockoutStore,
+		AccountPolicy: lockout.DefaultAccountPolicy,
+		IPPolicy:      lockout.DefaultIPPolicy,
 	})
 	authService := auth.NewService(auth.ServiceConfig{
-		UserRepo:       userRepo,
-		SessionService: sessionService,
-		TokenProvider:  *tokenProvider,
+		UserRepo:        userRepo,
+		UserTokenRepo:   userTokenRepo,
+		SessionService:  sessionService,
+		MFAService:      mfaService,
+		LockoutService:  lockoutService,
+		TokenProvider:   *tokenProvider,
+		Mailer:          mailClient,
+		PasswordHistory: newPasswordHistory(cfg),
+	})
+	exportService := dataexport.NewService(dataexport.ServiceConfig{
+		ExportRepo:    dataexportRepository.NewRepo(pgConn.DB()),
+		UserRepo:      userRepo,
+		SessionRepo:   sessionRepo,
+		StorageClient: storageClient,
+		Mailer:        mailClient,
+		LinkTTL:       newExportLinkTTL(cfg),
 	})
 	categoryService := category.NewService(category.ServiceConfig{
 		CategoryRepo: categoryRepo,
+		Cache:        newCategoryCache(cfg),
 	})
 
-	authHandler.NewHandler(authService, cfg.JWTAccessKey).RegisterRoutes(router)
-	userHandler.NewHandler(userService, storageClient).RegisterRoutes(router)
-	categoryhandler.NewHandler(categoryService).RegisterRoutes(router)
+	jobsCtx, stopJobs := context.WithCancel(ctx)
+	defer stopJobs()
+	statsRefresh := parseInterval("CATEGORY_STATS_REFRESH", cfg.CategoryStatsRefresh, category.DefaultStatsRefreshInterval)
+	scheduler.Every(jobsCtx, "category_stats_refresh", statsRefresh, categoryService.RefreshStats)
+	anonymizeInterval := parseInterval("ACCOUNT_ANONYMIZE_INTERVAL", cfg.AnonymizeInterval, user.DefaultAnonymizeInterval)
+	scheduler.Every(jobsCtx, "user_anonymization", anonymizeInterval, userService.AnonymizeDeletedUsers)
+	exportInterval := parseInterval("DATA_EXPORT_INTERVAL", cfg.DataExportInterval, dataexport.DefaultInterval)
+	scheduler.Every(jobsCtx, "data_export_processing", exportInterval, exportService.ProcessPending)
+	scheduler.Every(jobsCtx, "data_export_cleanup", exportInterval, exportService.RemoveExpired)
+
+	locales := newLocales(cfg)
+	authMiddleware := middlewares.NewWithAuth(accessKeys, revocations)
+
+	authHandler.NewHandler(authService, mfaService, authMiddleware, rateLimits).RegisterRoutes(router)
+	userHandler.NewHandler(userService, authService, exportService, storageClient, authMiddleware, rateLimits).RegisterRoutes(router)
+	cacheControl := cfg.CategoryCacheControl
+	if cacheControl == "" {
+		cacheControl = categoryhandler.DefaultCacheControl
+	}
+	categoryhandler.NewHandler(categoryService, locales, cacheControl, authMiddleware, rateLimits).RegisterRoutes(router)
+	wellknownHandler.NewHandler(tokenProvider).RegisterRoutes(router)
+	adminHandler.NewHandler(userService, lockoutService, authMiddleware, rateLimits).RegisterRoutes(router)
 
 	srv := server.New(server.Config{
 		Port:         cfg.Port,
@@ -118,3 +227,169 @@ func main() {
 
 	slog.Info("server shoutdown gracefully")
 }
+
+// newRateLimiter builds the limiter of a route group. Groups without a
+// configured limit are left unlimited.
+func newRateLimiter(group, limit string, store ratelimit.Store, key middlewares.KeyFunc) *middlewares.RateLimiter {
+	if limit == "" {
+		return nil
+	}
+
+	l, err := ratelimit.ParseLimit(limit)
+	if err != nil {
+		slog.Error("invalid rate limit", "group", group, "error", err)
+		panic(err)
+	}
+
+	return middlewares.NewRateLimiter(middlewares.RateLimiterConfig{
+		Group: group,
+		Store: store,
+		Limit: l,
+		Key:   key,
+	})
+}
+
+// loadKeyrings reads the signing keys from JWT_KEY_DIR when it is set, and
+// from the JWT_*_KEY variables otherwise. Without mfa keys the mfa pending
+// tokens are signed with the refresh keys, which are not published either.
+func loadKeyrings(cfg *config.Config) (access, refresh, mfa *jwt.Keyring, err error) {
+	if cfg.JWTKeyDir != "" {
+		access, err = jwt.LoadKeyringDir(filepath.Join(cfg.JWTKeyDir, "access"))
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		refresh, err = jwt.LoadKeyringDir(filepath.Join(cfg.JWTKeyDir, "refresh"))
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		mfa, err = jwt.LoadKeyringDir(filepath.Join(cfg.JWTKeyDir, "mfa"))
+		if errors.Is(err, os.ErrNotExist) {
+			slog.Warn("no mfa signing keys, using the refresh keys", "dir", cfg.JWTKeyDir)
+			return access, refresh, refresh, nil
+		}
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		return access, refresh, mfa, nil
+	}
+
+	access, err = jwt.NewKeyring(cfg.JWTAccessKey, cfg.JWTAccessVerifyKeys...)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	refresh, err = jwt.NewKeyring(cfg.JWTRefreshKey, cfg.JWTRefreshVerifyKeys...)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if cfg.JWTMFAKey == nil {
+		slog.Warn("JWT_MFA_KEY not set, using the refresh keys for mfa pending tokens")
+		return access, refresh, refresh, nil
+	}
+	mfa, err = jwt.NewKeyring(cfg.JWTMFAKey, cfg.JWTMFAVerifyKeys...)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return access, refresh, mfa, nil
+}
+
+// newTrustedProxies reads TRUSTED_PROXIES, the proxies whose forwarded client
+// IP is believed.
+func newTrustedProxies(cfg *config.Config) []netip.Prefix {
+	trusted, err := httputils.ParseTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		slog.Error("invalid trusted proxies", "error", err)
+		panic(err)
+	}
+
+	return trusted
+}
+
+// newRevocations picks how revoked access tokens are detected, see
+// REVOCATION_MODE.
+func newRevocations(cfg *config.Config, db *sqlx.DB, sessionRepo session.SessionRepository) revocation.Service {
+	if cfg.RevocationMode == "session" {
+		return session.NewActiveSessionChecker(sessionRepo, 5*time.Second)
+	}
+
+	var store revocation.Store = memory.NewRevocationStore()
+	if cfg.RevocationStore == "postgres" {
+		store = revocationRepository.NewRepo(db)
+	}
+
+	return revocation.NewService(revocation.ServiceConfig{
+		Store:    store,
+		TokenTTL: jwt.AccessTokenDuration,
+	})
+}
+
+// newLocales builds the locales category names are served in. The names
+// stored in categories are in DEFAULT_LOCALE, which falls back to the mail
+// templates' locale.
+func newLocales(cfg *config.Config) *locale.Matcher {
+	defaultLocale := cfg.DefaultLocale
+	if defaultLocale == "" {
+		defaultLocale = mailer.DefaultLocale
+	}
+
+	locales, err := locale.NewMatcher(defaultLocale, strings.Split(cfg.SupportedLocales, ",")...)
+	if err != nil {
+		slog.Error("invalid locales", "error", err)
+		panic(err)
+	}
+
+	return locales
+}
+
+// newCategoryCache keeps the public category reads for CATEGORY_CACHE_TTL, a
+// duration such as 1m; 0 turns the cache off.
+func newCategoryCache(cfg *config.Config) category.Cache {
+	if cfg.CategoryCacheTTL == "0" {
+		return nil
+	}
+
+	return memory.NewCache(parseInterval("CATEGORY_CACHE_TTL", cfg.CategoryCacheTTL, time.Minute))
+}
+
+// newExportLinkTTL reads how long data export links work. Storage links
+// cannot outlive dataexport.MaxLinkTTL.
+func newExportLinkTTL(cfg *config.Config) time.Duration {
+	ttl := parseInterval("DATA_EXPORT_LINK_TTL", cfg.DataExportLinkTTL, dataexport.DefaultLinkTTL)
+	if ttl > dataexport.MaxLinkTTL {
+		slog.Error("data export link ttl too long", "value", cfg.DataExportLinkTTL, "max", dataexport.MaxLinkTTL)
+		panic(fmt.Sprintf("invalid DATA_EXPORT_LINK_TTL %q", cfg.DataExportLinkTTL))
+	}
+
+	return ttl
+}
+
+// newPasswordHistory reads how many of the latest passwords cannot be
+// reused, falling back to auth.DefaultPasswordHistory.
+func newPasswordHistory(cfg *config.Config) int {
+	if cfg.PasswordHistory == "" {
+		return auth.DefaultPasswordHistory
+	}
+
+	n, err := strconv.Atoi(cfg.PasswordHistory)
+	if err != nil || n < 1 {
+		slog.Error("invalid password history", "value", cfg.PasswordHistory, "error", err)
+		panic(fmt.Sprintf("invalid PASSWORD_HISTORY %q", cfg.PasswordHistory))
+	}
+
+	return n
+}
+
+// parseInterval reads a positive duration such as 5m from the named
+// setting, or returns fallback when it is empty.
+func parseInterval(name, value string, fallback time.Duration) time.Duration {
+	if value == "" {
+		return fallback
+	}
+
+	interval, err := time.ParseDuration(value)
+	if err != nil || interval <= 0 {
+		slog.Error("invalid interval", "setting", name, "value", value, "error", err)
+		panic(fmt.Sprintf("invalid %s %q", name, value))
+	}
+
+	return interval
+}
@@ -1,12 +1,11 @@
 package config
 
 import (
-	"crypto/rsa"
-	"crypto/x509"
+	stdcrypto "crypto"
 	"encoding/base64"
-	"encoding/pem"
 	"fmt"
 	"log"
+	"msn/pkg/utils/crypto"
 	"reflect"
 	"strings"
 	"sync"
@@ -21,16 +20,49 @@ var (
 )
 
 type Config struct {
-	Port             string          `mapstructure:"PORT"`
-	Environment      string          `mapstructure:"ENVIRONMENT"`
-	AppName          string          `mapstructure:"APP_NAME"`
-	DebugMode        bool            `mapstructure:"DEBUG"`
-	PostgresDSN      string          `mapstructure:"DB_POSTGRES_DSN"`
-	StorageURL       string          `mapstructure:"STORAGE_URL"`
-	StorageAccessKey string          `mapstructure:"STORAGE_ACCESS_KEY"`
-	StorageSecretKey string          `mapstructure:"STORAGE_SECRET_KEY"`
-	JWTAccessKey     *rsa.PrivateKey `mapstructure:"JWT_ACCESS_KEY"`
-	JWTRefreshKey    *rsa.PrivateKey `mapstructure:"JWT_REFRESH_KEY"`
+	Port                 string             `mapstructure:"PORT"`
+	Environment          string             `mapstructure:"ENVIRONMENT"`
+	AppName              string             `mapstructure:"APP_NAME"`
+	DebugMode            bool               `mapstructure:"DEBUG"`
+	PostgresDSN          string             `mapstructure:"DB_POSTGRES_DSN"`
+	StorageURL           string             `mapstructure:"STORAGE_URL"`
+	StorageAccessKey     string             `mapstructure:"STORAGE_ACCESS_KEY"`
+	StorageSecretKey     string             `mapstructure:"STORAGE_SECRET_KEY"`
+	AppURL               string             `mapstructure:"APP_URL"`
+	FrontendURL          string             `mapstructure:"FRONTEND_URL"`
+	MailDriver           string             `mapstructure:"MAIL_DRIVER"`
+	MailFrom             string             `mapstructure:"MAIL_FROM"`
+	MailOutboxDir        string             `mapstructure:"MAIL_OUTBOX_DIR"`
+	SMTPHost             string             `mapstructure:"SMTP_HOST"`
+	SMTPPort             string             `mapstructure:"SMTP_PORT"`
+	SMTPUsername         string             `mapstructure:"SMTP_USERNAME"`
+	SMTPPassword         string             `mapstructure:"SMTP_PASSWORD"`
+	TrustedProxies       string             `mapstructure:"TRUSTED_PROXIES"`
+	LockoutStore         string             `mapstructure:"LOCKOUT_STORE"`
+	RateLimitStore       string             `mapstructure:"RATE_LIMIT_STORE"`
+	RateLimitAuth        string             `mapstructure:"RATE_LIMIT_AUTH"`
+	RateLimitRegister    string             `mapstructure:"RATE_LIMIT_REGISTER"`
+	RateLimitPublic      string             `mapstructure:"RATE_LIMIT_PUBLIC"`
+	RateLimitUser        string             `mapstructure:"RATE_LIMIT_USER"`
+	RevocationMode       string             `mapstructure:"REVOCATION_MODE"`
+	RevocationStore      string             `mapstructure:"REVOCATION_STORE"`
+	DefaultLocale        string             `mapstructure:"DEFAULT_LOCALE"`
+	SupportedLocales     string             `mapstructure:"SUPPORTED_LOCALES"`
+	CategoryStatsRefresh string             `mapstructure:"CATEGORY_STATS_REFRESH"`
+	CategoryCacheTTL     string             `mapstructure:"CATEGORY_CACHE_TTL"`
+	CategoryCacheControl string             `mapstructure:"CATEGORY_CACHE_CONTROL"`
+	PasswordHistory      string             `mapstructure:"PASSWORD_HISTORY"`
+	AccountDeletionGrace string             `mapstructure:"ACCOUNT_DELETION_GRACE"`
+	AnonymizeInterval    string             `mapstructure:"ACCOUNT_ANONYMIZE_INTERVAL"`
+	DataExportLinkTTL    string             `mapstructure:"DATA_EXPORT_LINK_TTL"`
+	DataExportInterval   string             `mapstructure:"DATA_EXPORT_INTERVAL"`
+	JWTKeyDir            string             `mapstructure:"JWT_KEY_DIR"`
+	JWTAccessKey         stdcrypto.Signer   `mapstructure:"JWT_ACCESS_KEY"`
+	JWTRefreshKey        stdcrypto.Signer   `mapstructure:"JWT_REFRESH_KEY"`
+	JWTAccessVerifyKeys  []stdcrypto.Signer `mapstructure:"JWT_ACCESS_VERIFY_KEYS"`
+	JWTRefreshVerifyKeys []stdcrypto.Signer `mapstructure:"JWT_REFRESH_VERIFY_KEYS"`
+	JWTMFAKey            stdcrypto.Signer   `mapstructure:"JWT_MFA_KEY"`
+	JWTMFAVerifyKeys     []stdcrypto.Signer `mapstructure:"JWT_MFA_VERIFY_KEYS"`
 }
 
 func GetConfig() *Config {
@@ -86,13 +118,34 @@ func stringToBoolHook(
 func stringToPrivateKeyHook(
 	from reflect.Type, to reflect.Type, data any,
 ) (any, error) {
-	if from.Kind() == reflect.String && to == reflect.TypeOf((*rsa.PrivateKey)(nil)) {
+	if from.Kind() != reflect.String {
+		return data, nil
+	}
+
+	switch to {
+	case reflect.TypeOf((*stdcrypto.Signer)(nil)).Elem():
+		if data.(string) == "" {
+			return nil, nil
+		}
 		return loadPrivateKeyFromPEM(data.(string))
+	case reflect.TypeOf([]stdcrypto.Signer(nil)):
+		var keys []stdcrypto.Signer
+		for _, v := range strings.Split(data.(string), ",") {
+			if strings.TrimSpace(v) == "" {
+				continue
+			}
+			key, err := loadPrivateKeyFromPEM(strings.TrimSpace(v))
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, key)
+		}
+		return keys, nil
 	}
 	return data, nil
 }
 
-func loadPrivateKeyFromPEM(pemStr string) (*rsa.PrivateKey, error) {
+func loadPrivateKeyFromPEM(pemStr string) (stdcrypto.Signer, error) {
 	pemStr = strings.ReplaceAll(pemStr, "\\n", "\n")
 
 	decoded, err := base64.StdEncoding.DecodeString(pemStr)
@@ -100,18 +153,5 @@ func loadPrivateKeyFromPEM(pemStr string) (*rsa.PrivateKey, error) {
 		decoded = []byte(pemStr)
 	}
 
-	block, _ := pem.Decode(decoded)
-	if block == nil {
-		return nil, fmt.Errorf("invalid PEM: no block found")
-	}
-
-	keyIfc, err := x509.ParsePKCS8PrivateKey(block.Bytes)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse PKCS#8 key: %w", err)
-	}
-	rsaKey, ok := keyIfc.(*rsa.PrivateKey)
-	if !ok {
-		return nil, fmt.Errorf("parsed key is not RSA")
-	}
-	return rsaKey, nil
+	return crypto.ParsePrivateKeyPEM(decoded)
 }
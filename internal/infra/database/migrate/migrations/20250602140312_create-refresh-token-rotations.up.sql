@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS "refresh_token_rotations" (
+  "jti" VARCHAR(255) PRIMARY KEY,
+  "session_id" VARCHAR(255) NOT NULL REFERENCES sessions(id) ON DELETE CASCADE,
+  "rotated_at" TIMESTAMP DEFAULT NOW() NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS "refresh_token_rotations_session_id_idx" ON "refresh_token_rotations" ("session_id");
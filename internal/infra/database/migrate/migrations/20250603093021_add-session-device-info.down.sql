@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS "sessions_user_id_active_idx";
+
+ALTER TABLE "sessions"
+  DROP COLUMN IF EXISTS "device_label",
+  DROP COLUMN IF EXISTS "user_agent",
+  DROP COLUMN IF EXISTS "ip_address",
+  DROP COLUMN IF EXISTS "last_seen_at";
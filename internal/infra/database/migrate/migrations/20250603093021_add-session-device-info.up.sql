@@ -0,0 +1,7 @@
+ALTER TABLE "sessions"
+  ADD COLUMN IF NOT EXISTS "device_label" VARCHAR(255) NOT NULL DEFAULT '',
+  ADD COLUMN IF NOT EXISTS "user_agent" TEXT NOT NULL DEFAULT '',
+  ADD COLUMN IF NOT EXISTS "ip_address" VARCHAR(45) NOT NULL DEFAULT '',
+  ADD COLUMN IF NOT EXISTS "last_seen_at" TIMESTAMP NOT NULL DEFAULT NOW();
+
+CREATE INDEX IF NOT EXISTS "sessions_user_id_active_idx" ON "sessions" ("user_id", "active");
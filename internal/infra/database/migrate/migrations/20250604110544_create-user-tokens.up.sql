@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS "user_tokens" (
+  "id" VARCHAR(255) PRIMARY KEY,
+  "user_id" VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  "purpose" VARCHAR(64) NOT NULL,
+  "token_hash" VARCHAR(64) UNIQUE NOT NULL,
+  "expires_at" TIMESTAMP NOT NULL,
+  "used_at" TIMESTAMP,
+  "created_at" TIMESTAMP DEFAULT NOW() NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS "user_tokens_user_id_purpose_idx" ON "user_tokens" ("user_id", "purpose");
@@ -0,0 +1,4 @@
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "verified_at" TIMESTAMP;
+
+-- Accounts created before verification existed are trusted as verified.
+UPDATE "users" SET "verified_at" = "created_at" WHERE "verified_at" IS NULL;
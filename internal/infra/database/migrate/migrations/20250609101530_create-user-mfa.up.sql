@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS "user_mfa" (
+  "user_id" VARCHAR(255) PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+  "secret" VARCHAR(255) NOT NULL,
+  "last_used_step" BIGINT NOT NULL DEFAULT 0,
+  "enabled_at" TIMESTAMP,
+  "created_at" TIMESTAMP DEFAULT NOW() NOT NULL,
+  "updated_at" TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS "mfa_recovery_codes" (
+  "id" VARCHAR(255) PRIMARY KEY,
+  "user_id" VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  "code_hash" VARCHAR(64) NOT NULL,
+  "used_at" TIMESTAMP,
+  "created_at" TIMESTAMP DEFAULT NOW() NOT NULL,
+  UNIQUE ("user_id", "code_hash")
+);
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS "login_attempts" (
+  "key" VARCHAR(320) PRIMARY KEY,
+  "failures" INTEGER NOT NULL DEFAULT 0,
+  "first_failure_at" TIMESTAMP NOT NULL,
+  "last_failure_at" TIMESTAMP NOT NULL,
+  "locked_until" TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS "login_attempts_last_failure_at_idx" ON "login_attempts" ("last_failure_at");
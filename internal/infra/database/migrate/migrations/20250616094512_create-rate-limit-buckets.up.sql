@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS "rate_limit_buckets" (
+  "key" VARCHAR(320) PRIMARY KEY,
+  "tokens" DOUBLE PRECISION NOT NULL,
+  "updated_at" TIMESTAMP NOT NULL,
+  "expires_at" TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS "rate_limit_buckets_expires_at_idx" ON "rate_limit_buckets" ("expires_at");
@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS "access_token_denylist" (
+  "key" VARCHAR(255) PRIMARY KEY,
+  "expires_at" TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS "access_token_denylist_expires_at_idx" ON "access_token_denylist" ("expires_at");
@@ -0,0 +1,11 @@
+DROP TABLE IF EXISTS "role_permissions";
+
+UPDATE "users"
+SET "role_id" = (SELECT "id" FROM "roles" WHERE "name" = 'client')
+WHERE "role_id" = (SELECT "id" FROM "roles" WHERE "name" = 'admin');
+
+DELETE FROM "roles" WHERE "name" = 'admin';
+
+ALTER TABLE "roles"
+  DROP COLUMN IF EXISTS "subcategory_rule",
+  DROP COLUMN IF EXISTS "self_assignable";
@@ -0,0 +1,24 @@
+ALTER TABLE "roles"
+  ADD COLUMN IF NOT EXISTS "subcategory_rule" VARCHAR(16) NOT NULL DEFAULT 'forbidden',
+  ADD COLUMN IF NOT EXISTS "self_assignable" BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS "role_permissions" (
+  "role_id" VARCHAR(255) NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+  "permission" VARCHAR(255) NOT NULL,
+  "created_at" TIMESTAMP DEFAULT NOW() NOT NULL,
+  PRIMARY KEY ("role_id", "permission")
+);
+
+UPDATE "roles" SET "self_assignable" = TRUE WHERE "name" IN ('client', 'professional');
+UPDATE "roles" SET "subcategory_rule" = 'required' WHERE "name" = 'professional';
+
+INSERT INTO "roles" ("name")
+VALUES ('admin')
+ON CONFLICT ("name") DO NOTHING;
+
+INSERT INTO "role_permissions" ("role_id", "permission")
+SELECT r.id, p.permission
+FROM "roles" r
+CROSS JOIN (VALUES ('categories:write'), ('roles:assign'), ('users:unlock')) AS p(permission)
+WHERE r.name = 'admin'
+ON CONFLICT DO NOTHING;
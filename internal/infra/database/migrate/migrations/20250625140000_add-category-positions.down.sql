@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS "users_subcategory_id_idx";
+
+ALTER TABLE "subcategories" DROP COLUMN IF EXISTS "position";
+ALTER TABLE "categories" DROP COLUMN IF EXISTS "position";
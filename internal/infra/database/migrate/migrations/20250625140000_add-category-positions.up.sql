@@ -0,0 +1,4 @@
+ALTER TABLE "categories" ADD COLUMN IF NOT EXISTS "position" INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE "subcategories" ADD COLUMN IF NOT EXISTS "position" INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS "users_subcategory_id_idx" ON "users" ("subcategory_id");
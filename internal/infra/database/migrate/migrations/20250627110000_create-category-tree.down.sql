@@ -0,0 +1,39 @@
+-- Only trees at most two levels deep can be turned back into categories and
+-- subcategories; deeper nodes are attached to their root category.
+CREATE TABLE IF NOT EXISTS subcategories (
+  id          VARCHAR(255) PRIMARY KEY DEFAULT new_id('subcategory'),
+  name        VARCHAR(255) NOT NULL UNIQUE,
+  category_id VARCHAR(255) NOT NULL
+               REFERENCES categories(id),
+  position    INTEGER     NOT NULL DEFAULT 0,
+  created_at  TIMESTAMP   NOT NULL DEFAULT NOW(),
+  updated_at  TIMESTAMP,
+  deleted_at  TIMESTAMP,
+  UNIQUE (name, category_id)
+);
+
+INSERT INTO "subcategories" ("id", "name", "category_id", "position", "created_at", "updated_at", "deleted_at")
+SELECT "id", "name", split_part("path", '/', 2), "position", "created_at", "updated_at", "deleted_at"
+FROM "categories"
+WHERE "parent_id" IS NOT NULL;
+
+ALTER TABLE "users" DROP CONSTRAINT IF EXISTS "users_subcategory_id_fkey";
+UPDATE "users" SET "subcategory_id" = NULL
+WHERE "subcategory_id" IS NOT NULL
+  AND "subcategory_id" NOT IN (SELECT "id" FROM "subcategories");
+ALTER TABLE "users"
+  ADD CONSTRAINT "users_subcategory_id_fkey" FOREIGN KEY ("subcategory_id") REFERENCES subcategories(id) ON DELETE SET NULL;
+
+DELETE FROM "categories" WHERE "parent_id" IS NOT NULL;
+
+DROP INDEX IF EXISTS "categories_path_idx";
+DROP INDEX IF EXISTS "categories_parent_id_idx";
+DROP INDEX IF EXISTS "categories_parent_id_name_key";
+
+ALTER TABLE "categories"
+  DROP CONSTRAINT IF EXISTS "categories_slug_key",
+  DROP COLUMN IF EXISTS "depth",
+  DROP COLUMN IF EXISTS "path",
+  DROP COLUMN IF EXISTS "slug",
+  DROP COLUMN IF EXISTS "parent_id",
+  ADD CONSTRAINT "categories_name_key" UNIQUE ("name");
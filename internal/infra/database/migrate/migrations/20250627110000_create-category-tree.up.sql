@@ -0,0 +1,50 @@
+ALTER TABLE "categories"
+  ADD COLUMN IF NOT EXISTS "parent_id" VARCHAR(255) REFERENCES categories(id),
+  ADD COLUMN IF NOT EXISTS "slug" VARCHAR(255),
+  ADD COLUMN IF NOT EXISTS "path" TEXT,
+  ADD COLUMN IF NOT EXISTS "depth" INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE "categories" DROP CONSTRAINT IF EXISTS "categories_name_key";
+
+-- Subcategories become children of their category. They keep their ids, so
+-- users.subcategory_id stays valid and now points at a category node.
+INSERT INTO "categories" ("id", "name", "parent_id", "position", "depth", "created_at", "updated_at", "deleted_at")
+SELECT "id", "name", "category_id", "position", 1, "created_at", "updated_at", "deleted_at"
+FROM "subcategories";
+
+-- The path lists the ids from the root down to the node itself, e.g.
+-- /category_a/subcategory_b/.
+UPDATE "categories" SET "path" = '/' || "id" || '/' WHERE "parent_id" IS NULL;
+UPDATE "categories" c SET "path" = p."path" || c."id" || '/'
+FROM "categories" p
+WHERE c."parent_id" = p."id";
+
+WITH "slugs" AS (
+  SELECT "id", trim(BOTH '-' FROM regexp_replace(
+    translate(lower("name"), 'áàâãäéèêëíìîïóòôõöúùûüçñ', 'aaaaaeeeeiiiiooooouuuucn'),
+    '[^a-z0-9]+', '-', 'g'
+  )) AS "slug"
+  FROM "categories"
+), "numbered" AS (
+  SELECT "id", "slug", ROW_NUMBER() OVER (PARTITION BY "slug" ORDER BY "depth", "id") AS "n"
+  FROM "slugs" JOIN "categories" USING ("id")
+)
+UPDATE "categories" c
+SET "slug" = CASE WHEN n."n" = 1 THEN n."slug" ELSE n."slug" || '-' || n."n" END
+FROM "numbered" n
+WHERE c."id" = n."id";
+
+ALTER TABLE "categories"
+  ALTER COLUMN "slug" SET NOT NULL,
+  ALTER COLUMN "path" SET NOT NULL,
+  ADD CONSTRAINT "categories_slug_key" UNIQUE ("slug");
+
+CREATE UNIQUE INDEX IF NOT EXISTS "categories_parent_id_name_key" ON "categories" (COALESCE("parent_id", ''), "name");
+CREATE INDEX IF NOT EXISTS "categories_parent_id_idx" ON "categories" ("parent_id");
+CREATE INDEX IF NOT EXISTS "categories_path_idx" ON "categories" ("path" text_pattern_ops);
+
+ALTER TABLE "users" DROP CONSTRAINT IF EXISTS "users_subcategory_id_fkey";
+ALTER TABLE "users"
+  ADD CONSTRAINT "users_subcategory_id_fkey" FOREIGN KEY ("subcategory_id") REFERENCES categories(id) ON DELETE SET NULL;
+
+DROP TABLE IF EXISTS "subcategories";
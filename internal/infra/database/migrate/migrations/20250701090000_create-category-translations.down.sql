@@ -0,0 +1 @@
+DROP TABLE IF EXISTS "category_translations";
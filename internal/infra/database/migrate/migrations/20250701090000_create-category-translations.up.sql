@@ -0,0 +1,116 @@
+CREATE TABLE IF NOT EXISTS "category_translations" (
+  "category_id" VARCHAR(255) NOT NULL REFERENCES categories(id) ON DELETE CASCADE,
+  "locale" VARCHAR(16) NOT NULL,
+  "name" VARCHAR(255) NOT NULL,
+  "created_at" TIMESTAMP DEFAULT NOW() NOT NULL,
+  "updated_at" TIMESTAMP,
+  PRIMARY KEY ("category_id", "locale")
+);
+
+-- categories.name keeps the name in the default locale (pt-BR); this table
+-- only holds the other locales. Seeded nodes are matched by slug, so any
+-- that were renamed or removed are skipped.
+INSERT INTO "category_translations" ("category_id", "locale", "name")
+SELECT c.id, t.locale, t.name
+FROM (VALUES
+  ('servicos-domesticos-e-reparos', 'en', 'Home Services and Repairs'),
+  ('servicos-domesticos-e-reparos', 'es', 'Servicios Domésticos y Reparaciones'),
+  ('beleza-e-estetica', 'en', 'Beauty and Aesthetics'),
+  ('beleza-e-estetica', 'es', 'Belleza y Estética'),
+  ('negocios-e-consultoria', 'en', 'Business and Consulting'),
+  ('negocios-e-consultoria', 'es', 'Negocios y Consultoría'),
+  ('arte-musica-e-eventos', 'en', 'Art, Music and Events'),
+  ('arte-musica-e-eventos', 'es', 'Arte, Música y Eventos'),
+  ('transporte-e-veiculos', 'en', 'Transportation and Vehicles'),
+  ('transporte-e-veiculos', 'es', 'Transporte y Vehículos'),
+  ('educacao-e-ensino', 'en', 'Education and Teaching'),
+  ('educacao-e-ensino', 'es', 'Educación y Enseñanza'),
+  ('saude-e-bem-estar', 'en', 'Health and Wellness'),
+  ('saude-e-bem-estar', 'es', 'Salud y Bienestar'),
+  ('eletricistas', 'en', 'Electricians'),
+  ('eletricistas', 'es', 'Electricistas'),
+  ('encanadores', 'en', 'Plumbers'),
+  ('encanadores', 'es', 'Fontaneros'),
+  ('pedreiros', 'en', 'Bricklayers'),
+  ('pedreiros', 'es', 'Albañiles'),
+  ('pintores', 'en', 'Painters'),
+  ('pintores', 'es', 'Pintores'),
+  ('jardineiros', 'en', 'Gardeners'),
+  ('jardineiros', 'es', 'Jardineros'),
+  ('marceneiros', 'en', 'Carpenters'),
+  ('marceneiros', 'es', 'Carpinteros'),
+  ('tecnicos-de-eletrodomesticos', 'en', 'Appliance technicians'),
+  ('tecnicos-de-eletrodomesticos', 'es', 'Técnicos de electrodomésticos'),
+  ('limpadores-de-piscina', 'en', 'Pool cleaners'),
+  ('limpadores-de-piscina', 'es', 'Limpiadores de piscinas'),
+  ('diaristas', 'en', 'Housekeepers'),
+  ('diaristas', 'es', 'Personal de limpieza'),
+  ('babas', 'en', 'Nannies'),
+  ('babas', 'es', 'Niñeras'),
+  ('cuidadores-de-idosos', 'en', 'Elderly caregivers'),
+  ('cuidadores-de-idosos', 'es', 'Cuidadores de personas mayores'),
+  ('segurancas-particulares', 'en', 'Private security guards'),
+  ('segurancas-particulares', 'es', 'Guardias de seguridad privada'),
+  ('cabeleireiros', 'en', 'Hairdressers'),
+  ('cabeleireiros', 'es', 'Peluqueros'),
+  ('manicures', 'en', 'Manicurists'),
+  ('manicures', 'es', 'Manicuristas'),
+  ('maquiadores', 'en', 'Makeup artists'),
+  ('maquiadores', 'es', 'Maquilladores'),
+  ('esteticistas', 'en', 'Beauticians'),
+  ('esteticistas', 'es', 'Esteticistas'),
+  ('massagistas', 'en', 'Massage therapists'),
+  ('massagistas', 'es', 'Masajistas'),
+  ('advogados', 'en', 'Lawyers'),
+  ('advogados', 'es', 'Abogados'),
+  ('contadores', 'en', 'Accountants'),
+  ('contadores', 'es', 'Contadores'),
+  ('consultores', 'en', 'Consultants'),
+  ('consultores', 'es', 'Consultores'),
+  ('tradutores', 'en', 'Translators'),
+  ('tradutores', 'es', 'Traductores'),
+  ('revisores-de-texto', 'en', 'Proofreaders'),
+  ('revisores-de-texto', 'es', 'Correctores de textos'),
+  ('copywriters', 'en', 'Copywriters'),
+  ('copywriters', 'es', 'Redactores publicitarios'),
+  ('social-media', 'en', 'Social media managers'),
+  ('social-media', 'es', 'Gestores de redes sociales'),
+  ('fotografos', 'en', 'Photographers'),
+  ('fotografos', 'es', 'Fotógrafos'),
+  ('musicos', 'en', 'Musicians'),
+  ('musicos', 'es', 'Músicos'),
+  ('djs', 'en', 'DJs'),
+  ('djs', 'es', 'DJs'),
+  ('cerimonialistas', 'en', 'Event planners'),
+  ('cerimonialistas', 'es', 'Organizadores de ceremonias'),
+  ('decoradores-de-eventos', 'en', 'Event decorators'),
+  ('decoradores-de-eventos', 'es', 'Decoradores de eventos'),
+  ('cozinheiros-para-eventos', 'en', 'Event cooks'),
+  ('cozinheiros-para-eventos', 'es', 'Cocineros para eventos'),
+  ('buffets', 'en', 'Caterers'),
+  ('buffets', 'es', 'Servicios de catering'),
+  ('motoristas-particulares', 'en', 'Private drivers'),
+  ('motoristas-particulares', 'es', 'Conductores particulares'),
+  ('motoboys', 'en', 'Motorcycle couriers'),
+  ('motoboys', 'es', 'Mensajeros en moto'),
+  ('mecanicos', 'en', 'Mechanics'),
+  ('mecanicos', 'es', 'Mecánicos'),
+  ('lavadores-de-carro', 'en', 'Car washers'),
+  ('lavadores-de-carro', 'es', 'Lavadores de autos'),
+  ('instrutores-de-direcao', 'en', 'Driving instructors'),
+  ('instrutores-de-direcao', 'es', 'Instructores de manejo'),
+  ('professores', 'en', 'Teachers'),
+  ('professores', 'es', 'Profesores'),
+  ('acompanhantes-pedagogicos', 'en', 'Learning support assistants'),
+  ('acompanhantes-pedagogicos', 'es', 'Acompañantes pedagógicos'),
+  ('monitores-de-reforco-escolar', 'en', 'Tutors'),
+  ('monitores-de-reforco-escolar', 'es', 'Tutores de refuerzo escolar'),
+  ('interpretes-de-libras', 'en', 'Brazilian Sign Language interpreters'),
+  ('interpretes-de-libras', 'es', 'Intérpretes de lengua de señas brasileña'),
+  ('nutricionistas', 'en', 'Nutritionists'),
+  ('nutricionistas', 'es', 'Nutricionistas'),
+  ('personal-trainers', 'en', 'Personal trainers'),
+  ('personal-trainers', 'es', 'Entrenadores personales')
+) AS t(slug, locale, name)
+JOIN "categories" c ON c.slug = t.slug
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,2 @@
+DROP MATERIALIZED VIEW IF EXISTS "category_daily_signups";
+DROP MATERIALIZED VIEW IF EXISTS "category_professional_counts";
@@ -0,0 +1,32 @@
+-- Active professionals attached to each node or to any node below it. Like the
+-- professionals listing, only verified accounts count.
+CREATE MATERIALIZED VIEW IF NOT EXISTS "category_professional_counts" AS
+SELECT
+  c.id AS category_id,
+  COUNT(u.id) AS professionals,
+  NOW() AS refreshed_at
+FROM "categories" c
+LEFT JOIN "categories" n ON left(n.path, length(c.path)) = c.path AND n.deleted_at IS NULL
+LEFT JOIN (
+  "users" u JOIN "roles" r ON r.id = u.role_id AND r.name = 'professional'
+) ON u.subcategory_id = n.id AND u.deleted_at IS NULL AND u.verified_at IS NOT NULL
+WHERE c.deleted_at IS NULL
+GROUP BY c.id;
+
+CREATE UNIQUE INDEX IF NOT EXISTS "category_professional_counts_category_id_idx"
+  ON "category_professional_counts" ("category_id");
+
+-- Active professionals by the node they are attached to and the day they
+-- signed up.
+CREATE MATERIALIZED VIEW IF NOT EXISTS "category_daily_signups" AS
+SELECT
+  u.subcategory_id AS category_id,
+  u.created_at::date AS day,
+  COUNT(*) AS signups
+FROM "users" u
+JOIN "roles" r ON r.id = u.role_id AND r.name = 'professional'
+WHERE u.subcategory_id IS NOT NULL AND u.deleted_at IS NULL AND u.verified_at IS NOT NULL
+GROUP BY u.subcategory_id, u.created_at::date;
+
+CREATE UNIQUE INDEX IF NOT EXISTS "category_daily_signups_category_id_day_idx"
+  ON "category_daily_signups" ("category_id", "day");
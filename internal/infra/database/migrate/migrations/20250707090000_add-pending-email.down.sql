@@ -0,0 +1,2 @@
+ALTER TABLE "user_tokens" DROP COLUMN IF EXISTS "session_id";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "pending_email";
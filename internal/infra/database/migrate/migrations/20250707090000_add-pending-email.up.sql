@@ -0,0 +1,2 @@
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "pending_email" VARCHAR(255);
+ALTER TABLE "user_tokens" ADD COLUMN IF NOT EXISTS "session_id" VARCHAR(255);
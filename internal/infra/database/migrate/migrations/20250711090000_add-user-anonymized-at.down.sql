@@ -0,0 +1,5 @@
+DELETE FROM "role_permissions" WHERE "permission" = 'users:restore';
+
+DROP INDEX IF EXISTS "users_pending_anonymization_idx";
+
+ALTER TABLE "users" DROP COLUMN IF EXISTS "anonymized_at";
@@ -0,0 +1,10 @@
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "anonymized_at" TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS "users_pending_anonymization_idx" ON "users" ("deleted_at")
+WHERE "deleted_at" IS NOT NULL AND ("anonymized_at" IS NULL OR "avatar_url" <> '');
+
+INSERT INTO "role_permissions" ("role_id", "permission")
+SELECT r.id, 'users:restore'
+FROM "roles" r
+WHERE r.name = 'admin'
+ON CONFLICT DO NOTHING;
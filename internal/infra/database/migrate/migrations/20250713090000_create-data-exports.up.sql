@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS "data_exports" (
+  "id" VARCHAR(255) PRIMARY KEY,
+  "user_id" VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  "status" VARCHAR(16) NOT NULL DEFAULT 'pending',
+  "object_name" VARCHAR(255),
+  "attempts" INTEGER NOT NULL DEFAULT 0,
+  "started_at" TIMESTAMP,
+  "completed_at" TIMESTAMP,
+  "expires_at" TIMESTAMP,
+  "notified_at" TIMESTAMP,
+  "created_at" TIMESTAMP DEFAULT NOW() NOT NULL,
+  "updated_at" TIMESTAMP
+);
+
+-- A user has at most one export waiting or being built.
+CREATE UNIQUE INDEX IF NOT EXISTS "data_exports_user_id_in_progress_idx" ON "data_exports" ("user_id")
+WHERE "status" IN ('pending', 'processing');
+
+CREATE INDEX IF NOT EXISTS "data_exports_status_idx" ON "data_exports" ("status", "created_at");
@@ -4,9 +4,35 @@ import "time"
 
 type Category struct {
 	ID        string     `db:"id"`
+	ParentID  *string    `db:"parent_id"`
 	Name      string     `db:"name"`
+	Slug      string     `db:"slug"`
 	Icon      string     `db:"icon"`
+	Path      string     `db:"path"`
+	Depth     int        `db:"depth"`
+	Position  int        `db:"position"`
 	CreatedAt time.Time  `db:"created_at"`
 	UpdatedAt *time.Time `db:"updated_at"`
 	DeletedAt *time.Time `db:"deleted_at"`
 }
+
+type CategoryTranslation struct {
+	CategoryID string     `db:"category_id"`
+	Locale     string     `db:"locale"`
+	Name       string     `db:"name"`
+	CreatedAt  time.Time  `db:"created_at"`
+	UpdatedAt  *time.Time `db:"updated_at"`
+}
+
+type CategoryStats struct {
+	CategoryID    string     `db:"category_id"`
+	Professionals int        `db:"professionals"`
+	Signups       int        `db:"signups"`
+	RefreshedAt   *time.Time `db:"refreshed_at"`
+}
+
+type CategorySignups struct {
+	CategoryID string    `db:"category_id"`
+	Day        time.Time `db:"day"`
+	Signups    int       `db:"signups"`
+}
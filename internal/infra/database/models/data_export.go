@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+type DataExport struct {
+	ID          string     `db:"id"`
+	UserID      string     `db:"user_id"`
+	Status      string     `db:"status"`
+	ObjectName  *string    `db:"object_name"`
+	Attempts    int        `db:"attempts"`
+	StartedAt   *time.Time `db:"started_at"`
+	CompletedAt *time.Time `db:"completed_at"`
+	ExpiresAt   *time.Time `db:"expires_at"`
+	NotifiedAt  *time.Time `db:"notified_at"`
+	CreatedAt   time.Time  `db:"created_at"`
+	UpdatedAt   *time.Time `db:"updated_at"`
+}
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+type LoginAttempt struct {
+	Key            string     `db:"key"`
+	Failures       int        `db:"failures"`
+	FirstFailureAt time.Time  `db:"first_failure_at"`
+	LastFailureAt  time.Time  `db:"last_failure_at"`
+	LockedUntil    *time.Time `db:"locked_until"`
+}
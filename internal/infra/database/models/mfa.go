@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+type UserMFA struct {
+	UserID       string     `db:"user_id"`
+	Secret       string     `db:"secret"`
+	LastUsedStep int64      `db:"last_used_step"`
+	EnabledAt    *time.Time `db:"enabled_at"`
+	CreatedAt    time.Time  `db:"created_at"`
+	UpdatedAt    *time.Time `db:"updated_at"`
+}
+
+type MFARecoveryCode struct {
+	ID        string     `db:"id"`
+	UserID    string     `db:"user_id"`
+	CodeHash  string     `db:"code_hash"`
+	UsedAt    *time.Time `db:"used_at"`
+	CreatedAt time.Time  `db:"created_at"`
+}
@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+type RateLimitBucket struct {
+	Key       string    `db:"key"`
+	Tokens    float64   `db:"tokens"`
+	UpdatedAt time.Time `db:"updated_at"`
+	ExpiresAt time.Time `db:"expires_at"`
+}
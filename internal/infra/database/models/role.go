@@ -3,9 +3,11 @@ package models
 import "time"
 
 type Role struct {
-	ID        string     `db:"id"`
-	Name      string     `db:"name"`
-	CreatedAt time.Time  `db:"created_at"`
-	UpdatedAt *time.Time `db:"updated_at"`
-	DeletedAt *time.Time `db:"deleted_at"`
+	ID              string     `db:"id"`
+	Name            string     `db:"name"`
+	SubcategoryRule string     `db:"subcategory_rule"`
+	SelfAssignable  bool       `db:"self_assignable"`
+	CreatedAt       time.Time  `db:"created_at"`
+	UpdatedAt       *time.Time `db:"updated_at"`
+	DeletedAt       *time.Time `db:"deleted_at"`
 }
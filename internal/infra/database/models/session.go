@@ -3,11 +3,15 @@ package models
 import "time"
 
 type Session struct {
-	ID        string    `db:"id"`
-	UserID    string    `db:"user_id"`
-	JTI       string    `db:"jti"`
-	Active    bool      `db:"active"`
-	CreatedAt time.Time `db:"created_at"`
-	UpdatedAt time.Time `db:"updated_at"`
-	ExpiresAt time.Time `db:"expires_at"`
+	ID          string    `db:"id"`
+	UserID      string    `db:"user_id"`
+	JTI         string    `db:"jti"`
+	Active      bool      `db:"active"`
+	DeviceLabel string    `db:"device_label"`
+	UserAgent   string    `db:"user_agent"`
+	IPAddress   string    `db:"ip_address"`
+	LastSeenAt  time.Time `db:"last_seen_at"`
+	CreatedAt   time.Time `db:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at"`
+	ExpiresAt   time.Time `db:"expires_at"`
 }
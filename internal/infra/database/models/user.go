@@ -8,11 +8,15 @@ type User struct {
 	ID            string     `db:"id"`
 	Name          string     `db:"name"`
 	Email         string     `db:"email"`
+	PendingEmail  *string    `db:"pending_email"`
 	Password      string     `db:"password"`
 	AvatarURL     string     `db:"avatar_url"`
 	RoleID        string     `db:"role_id"`
 	SubcategoryID *string    `db:"subcategory_id"`
+	VerifiedAt    *time.Time `db:"verified_at"`
 	CreatedAt     time.Time  `db:"created_at"`
 	UpdatedAt     *time.Time `db:"updated_at"`
 	DeletedAt     *time.Time `db:"deleted_at"`
+	AnonymizedAt  *time.Time `db:"anonymized_at"`
+	Version       int        `db:"version"`
 }
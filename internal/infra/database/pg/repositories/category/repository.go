@@ -5,14 +5,24 @@ import (
 	"database/sql"
 	"encoding/json"
 	"errors"
+	"msn/internal/infra/database/models"
 	"msn/internal/modules/category"
 	"msn/pkg/common/dto"
 	"msn/pkg/common/fault"
 	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
+// columns selects a node c into models.Category. Only root nodes have icons.
+// The name is taken from the translation t when it is joined and exists.
+const columns = `c.id, c.parent_id, COALESCE(t.name, c.name) AS name, c.slug, COALESCE(c.icon, '') AS icon, c.path, c.depth, c.position, c.created_at, c.updated_at, c.deleted_at`
+
+// localized joins the translation of each node to the locale in $1. An empty
+// locale matches no translation and keeps the names as stored.
+const localized = `categories c LEFT JOIN category_translations t ON t.category_id = c.id AND t.locale = $1`
+
 type repo struct {
 	db *sqlx.DB
 }
@@ -21,7 +31,7 @@ func NewRepo(db *sqlx.DB) category.Repository {
 	return &repo{db: db}
 }
 
-func (r repo) GetCategories(ctx context.Context) ([]*dto.Category, error) {
+func (r repo) GetCategories(ctx context.Context, locale string) ([]*dto.Category, error) {
 	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
 	defer cancel()
 
@@ -32,15 +42,16 @@ func (r repo) GetCategories(ctx context.Context) ([]*dto.Category, error) {
 		`
 		SELECT 
 			c.id,
-			c.name,
-			c.icon,
-			COUNT(DISTINCT u.id) as users
-		FROM categories c
-		LEFT JOIN subcategories s ON s.category_id = c.id
-		LEFT JOIN users u ON u.subcategory_id = s.id
-		GROUP BY c.id
-		ORDER BY users DESC
+			COALESCE(t.name, c.name) AS name,
+			c.slug,
+			COALESCE(c.icon, '') AS icon,
+			COALESCE(p.professionals, 0) AS users
+		FROM `+localized+`
+		LEFT JOIN category_professional_counts p ON p.category_id = c.id
+		WHERE c.parent_id IS NULL AND c.deleted_at IS NULL
+		ORDER BY c.position, users DESC
 		`,
+		locale,
 	)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -52,7 +63,7 @@ func (r repo) GetCategories(ctx context.Context) ([]*dto.Category, error) {
 	return categories, nil
 }
 
-func (r repo) GetCategoriesWithSubcategories(ctx context.Context) ([]*dto.Category, error) {
+func (r repo) GetCategoriesWithSubcategories(ctx context.Context, locale string) ([]*dto.Category, error) {
 	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
 	defer cancel()
 
@@ -61,18 +72,32 @@ func (r repo) GetCategoriesWithSubcategories(ctx context.Context) ([]*dto.Catego
 		ctx,
 		`SELECT
 			c.id,
-			c.name,
-			c.icon,
+			COALESCE(t.name, c.name) AS name,
+			c.slug,
+			COALESCE(c.icon, '') AS icon,
+			COALESCE(p.professionals, 0) AS users,
 			COALESCE(
 				JSON_AGG(
-					json_build_object('id', s.id, 'name', s.name)
-					ORDER BY s.name
-				) FILTER (WHERE s.deleted_at IS NULL),
+					json_build_object(
+						'id', s.id,
+						'name', COALESCE(st.name, s.name),
+						'slug', s.slug,
+						'category_id', s.parent_id,
+						'users', COALESCE(sp.professionals, 0)
+					)
+					ORDER BY s.position, s.name
+				) FILTER (WHERE s.id IS NOT NULL),
 				'[]'
 		) AS subs
-		FROM categories c
-		LEFT JOIN subcategories s ON s.category_id = c.id
-		GROUP BY c.id`,
+		FROM `+localized+`
+		LEFT JOIN category_professional_counts p ON p.category_id = c.id
+		LEFT JOIN categories s ON s.parent_id = c.id AND s.deleted_at IS NULL
+		LEFT JOIN category_translations st ON st.category_id = s.id AND st.locale = $1
+		LEFT JOIN category_professional_counts sp ON sp.category_id = s.id
+		WHERE c.parent_id IS NULL AND c.deleted_at IS NULL
+		GROUP BY c.id, t.name, p.professionals
+		ORDER BY c.position, c.name`,
+		locale,
 	)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -84,12 +109,15 @@ func (r repo) GetCategoriesWithSubcategories(ctx context.Context) ([]*dto.Catego
 
 	for rows.Next() {
 		var category dto.Category
+		var users int
 		var rawSubs []byte
 
 		if err := rows.Scan(
 			&category.ID,
 			&category.Name,
+			&category.Slug,
 			&category.Icon,
+			&users,
 			&rawSubs,
 		); err != nil {
 			return nil, fault.New("failed to scan category", fault.WithError(err))
@@ -98,6 +126,7 @@ func (r repo) GetCategoriesWithSubcategories(ctx context.Context) ([]*dto.Catego
 		if err := json.Unmarshal(rawSubs, &category.Subs); err != nil {
 			return nil, fault.New("failed to unmarshal subcategories", fault.WithError(err))
 		}
+		category.Users = &users
 
 		categories = append(categories, &category)
 	}
@@ -108,3 +137,496 @@ func (r repo) GetCategoriesWithSubcategories(ctx context.Context) ([]*dto.Catego
 
 	return categories, nil
 }
+
+func (r repo) GetTree(ctx context.Context, locale string) ([]*category.Category, error) {
+	return r.list(
+		ctx,
+		"SELECT "+columns+" FROM "+localized+" WHERE c.deleted_at IS NULL ORDER BY c.depth, c.position, c.name",
+		locale,
+	)
+}
+
+func (r repo) GetSubtree(ctx context.Context, c *category.Category, locale string) ([]*category.Category, error) {
+	return r.list(
+		ctx,
+		`
+		SELECT `+columns+`
+		FROM `+localized+`
+		WHERE left(c.path, length($2::text)) = $2::text AND c.id <> $3 AND c.deleted_at IS NULL
+		ORDER BY c.depth, c.position, c.name
+		`,
+		locale,
+		c.Path(),
+		c.ID(),
+	)
+}
+
+func (r repo) GetByIDs(ctx context.Context, categoryIDs []string, locale string) ([]*category.Category, error) {
+	return r.list(
+		ctx,
+		"SELECT "+columns+" FROM "+localized+" WHERE c.id = ANY($2) ORDER BY c.depth, c.position, c.name",
+		locale,
+		pq.Array(categoryIDs),
+	)
+}
+
+func (r repo) list(ctx context.Context, query string, args ...any) ([]*category.Category, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	var categoryModels []models.Category
+	if err := r.db.SelectContext(ctx, &categoryModels, query, args...); err != nil {
+		return nil, fault.New("failed to retrieve categories", fault.WithError(err))
+	}
+
+	categories := make([]*category.Category, 0, len(categoryModels))
+	for _, m := range categoryModels {
+		categories = append(categories, category.NewFromModel(m))
+	}
+
+	return categories, nil
+}
+
+func (r repo) GetByID(ctx context.Context, categoryID string) (*category.Category, error) {
+	return r.get(ctx, "c.id = $2", "", categoryID)
+}
+
+func (r repo) GetBySlug(ctx context.Context, slug, locale string) (*category.Category, error) {
+	return r.get(ctx, "c.slug = $2", locale, slug)
+}
+
+func (r repo) get(ctx context.Context, where, locale string, args ...any) (*category.Category, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	var categoryModel models.Category
+	err := r.db.GetContext(
+		ctx,
+		&categoryModel,
+		"SELECT "+columns+" FROM "+localized+" WHERE "+where,
+		append([]any{locale}, args...)...,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fault.New("failed to retrieve category", fault.WithError(err))
+	}
+
+	return category.NewFromModel(categoryModel), nil
+}
+
+func (r repo) SlugExists(ctx context.Context, slug string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	var exists bool
+	err := r.db.GetContext(ctx, &exists, "SELECT EXISTS (SELECT 1 FROM categories WHERE slug = $1)", slug)
+	if err != nil {
+		return false, fault.New("failed to check category slug", fault.WithError(err))
+	}
+
+	return exists, nil
+}
+
+func (r repo) NextPosition(ctx context.Context, parentID *string) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	var position int
+	err := r.db.GetContext(
+		ctx,
+		&position,
+		"SELECT COALESCE(MAX(position) + 1, 0) FROM categories WHERE parent_id IS NOT DISTINCT FROM $1 AND deleted_at IS NULL",
+		parentID,
+	)
+	if err != nil {
+		return 0, fault.New("failed to compute category position", fault.WithError(err))
+	}
+
+	return position, nil
+}
+
+func (r repo) Create(ctx context.Context, c *category.Category) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	query := `
+		INSERT INTO categories (
+			id,
+			parent_id,
+			name,
+			slug,
+			icon,
+			path,
+			depth,
+			position,
+			created_at,
+			updated_at,
+			deleted_at
+		) VALUES (
+			:id,
+			:parent_id,
+			:name,
+			:slug,
+			NULLIF(:icon, ''),
+			:path,
+			:depth,
+			:position,
+			:created_at,
+			:updated_at,
+			:deleted_at
+		)
+	`
+	if _, err := r.db.NamedExecContext(ctx, query, c.Model()); err != nil {
+		return fault.New("failed to insert category", fault.WithError(err))
+	}
+
+	return nil
+}
+
+func (r repo) Update(ctx context.Context, c *category.Category) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	query := `
+		UPDATE categories SET
+			name = :name,
+			slug = :slug,
+			icon = NULLIF(:icon, ''),
+			position = :position,
+			updated_at = :updated_at,
+			deleted_at = :deleted_at
+		WHERE id = :id
+	`
+	if _, err := r.db.NamedExecContext(ctx, query, c.Model()); err != nil {
+		return fault.New("failed to update category", fault.WithError(err))
+	}
+
+	return nil
+}
+
+func (r repo) Reorder(ctx context.Context, parentID *string, ids []string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, fault.New(
+			"failed to begin transaction",
+			fault.WithTag(fault.DB_TRANSACTION),
+			fault.WithError(err),
+		)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(
+		ctx,
+		`
+		UPDATE categories c SET
+			position = o.position - 1,
+			updated_at = NOW()
+		FROM unnest($1::text[]) WITH ORDINALITY AS o(id, position)
+		WHERE c.id = o.id AND c.parent_id IS NOT DISTINCT FROM $2 AND c.deleted_at IS NULL
+		`,
+		pq.Array(ids),
+		parentID,
+	)
+	if err != nil {
+		return false, fault.New("failed to reorder categories", fault.WithError(err))
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fault.New("failed to reorder categories", fault.WithError(err))
+	}
+	if affected != int64(len(ids)) {
+		return false, nil
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fault.New(
+			"failed to commit category order",
+			fault.WithTag(fault.DB_TRANSACTION),
+			fault.WithError(err),
+		)
+	}
+
+	return true, nil
+}
+
+func (r repo) CountActiveChildren(ctx context.Context, categoryID string) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	var count int
+	err := r.db.GetContext(
+		ctx,
+		&count,
+		"SELECT COUNT(*) FROM categories WHERE parent_id = $1 AND deleted_at IS NULL",
+		categoryID,
+	)
+	if err != nil {
+		return 0, fault.New("failed to count child categories", fault.WithError(err))
+	}
+
+	return count, nil
+}
+
+func (r repo) CountUsers(ctx context.Context, categoryID string) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	var count int
+	err := r.db.GetContext(
+		ctx,
+		&count,
+		"SELECT COUNT(*) FROM users WHERE subcategory_id = $1 AND deleted_at IS NULL",
+		categoryID,
+	)
+	if err != nil {
+		return 0, fault.New("failed to count category users", fault.WithError(err))
+	}
+
+	return count, nil
+}
+
+func (r repo) Delete(ctx context.Context, c *category.Category, replacementID *string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, fault.New(
+			"failed to begin transaction",
+			fault.WithTag(fault.DB_TRANSACTION),
+			fault.WithError(err),
+		)
+	}
+	defer tx.Rollback()
+
+	// Locking the row makes users signing up into this node wait on their
+	// foreign key check until the deletion is decided.
+	if _, err := tx.ExecContext(ctx, "SELECT id FROM categories WHERE id = $1 FOR UPDATE", c.ID()); err != nil {
+		return false, fault.New("failed to lock category", fault.WithError(err))
+	}
+
+	if replacementID != nil {
+		_, err := tx.ExecContext(
+			ctx,
+			"UPDATE users SET subcategory_id = $1, updated_at = NOW() WHERE subcategory_id = $2 AND deleted_at IS NULL",
+			*replacementID,
+			c.ID(),
+		)
+		if err != nil {
+			return false, fault.New("failed to move category users", fault.WithError(err))
+		}
+	}
+
+	var inUse bool
+	err = tx.GetContext(
+		ctx,
+		&inUse,
+		"SELECT EXISTS (SELECT 1 FROM users WHERE subcategory_id = $1 AND deleted_at IS NULL)",
+		c.ID(),
+	)
+	if err != nil {
+		return false, fault.New("failed to check category users", fault.WithError(err))
+	}
+	if inUse {
+		return false, nil
+	}
+
+	m := c.Model()
+	_, err = tx.ExecContext(
+		ctx,
+		"UPDATE categories SET updated_at = $1, deleted_at = $2 WHERE id = $3",
+		m.UpdatedAt,
+		m.DeletedAt,
+		m.ID,
+	)
+	if err != nil {
+		return false, fault.New("failed to delete category", fault.WithError(err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fault.New(
+			"failed to commit category deletion",
+			fault.WithTag(fault.DB_TRANSACTION),
+			fault.WithError(err),
+		)
+	}
+
+	return true, nil
+}
+
+func (r repo) RefreshStats(ctx context.Context) error {
+	// Refreshing reads the whole users table, so it gets longer than the
+	// usual queries; CONCURRENTLY keeps the views readable meanwhile.
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	for _, view := range []string{"category_professional_counts", "category_daily_signups"} {
+		if _, err := r.db.ExecContext(ctx, "REFRESH MATERIALIZED VIEW CONCURRENTLY "+view); err != nil {
+			return fault.New("failed to refresh "+view, fault.WithError(err))
+		}
+	}
+
+	return nil
+}
+
+func (r repo) GetStats(ctx context.Context, since time.Time) ([]category.NodeStats, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	var statsModels []models.CategoryStats
+	err := r.db.SelectContext(
+		ctx,
+		&statsModels,
+		`
+		SELECT
+			c.id AS category_id,
+			COALESCE(p.professionals, 0) AS professionals,
+			COALESCE(SUM(s.signups), 0) AS signups,
+			p.refreshed_at
+		FROM categories c
+		LEFT JOIN category_professional_counts p ON p.category_id = c.id
+		LEFT JOIN categories n ON left(n.path, length(c.path)) = c.path
+		LEFT JOIN category_daily_signups s ON s.category_id = n.id AND s.day >= $1::date
+		WHERE c.deleted_at IS NULL
+		GROUP BY c.id, p.professionals, p.refreshed_at
+		`,
+		since.Format(time.DateOnly),
+	)
+	if err != nil {
+		return nil, fault.New("failed to retrieve category stats", fault.WithError(err))
+	}
+
+	stats := make([]category.NodeStats, 0, len(statsModels))
+	for _, m := range statsModels {
+		stats = append(stats, category.NodeStats{
+			CategoryID:    m.CategoryID,
+			Professionals: m.Professionals,
+			Signups:       m.Signups,
+			RefreshedAt:   m.RefreshedAt,
+		})
+	}
+
+	return stats, nil
+}
+
+func (r repo) GetSignupTrend(ctx context.Context, since time.Time) ([]category.DaySignups, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	var signupModels []models.CategorySignups
+	err := r.db.SelectContext(
+		ctx,
+		&signupModels,
+		`
+		SELECT
+			split_part(c.path, '/', 2) AS category_id,
+			s.day,
+			SUM(s.signups) AS signups
+		FROM category_daily_signups s
+		JOIN categories c ON c.id = s.category_id
+		WHERE s.day >= $1::date
+		GROUP BY split_part(c.path, '/', 2), s.day
+		ORDER BY s.day
+		`,
+		since.Format(time.DateOnly),
+	)
+	if err != nil {
+		return nil, fault.New("failed to retrieve category signups", fault.WithError(err))
+	}
+
+	signups := make([]category.DaySignups, 0, len(signupModels))
+	for _, m := range signupModels {
+		signups = append(signups, category.DaySignups{
+			CategoryID: m.CategoryID,
+			Day:        m.Day,
+			Signups:    m.Signups,
+		})
+	}
+
+	return signups, nil
+}
+
+func (r repo) GetTranslations(ctx context.Context, categoryID string) ([]*category.Translation, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	var translationModels []models.CategoryTranslation
+	err := r.db.SelectContext(
+		ctx,
+		&translationModels,
+		`
+		SELECT category_id, locale, name, created_at, updated_at
+		FROM category_translations
+		WHERE category_id = $1
+		ORDER BY locale
+		`,
+		categoryID,
+	)
+	if err != nil {
+		return nil, fault.New("failed to retrieve category translations", fault.WithError(err))
+	}
+
+	translations := make([]*category.Translation, 0, len(translationModels))
+	for _, m := range translationModels {
+		translations = append(translations, category.NewTranslationFromModel(m))
+	}
+
+	return translations, nil
+}
+
+func (r repo) SaveTranslation(ctx context.Context, t *category.Translation) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	query := `
+		INSERT INTO category_translations (
+			category_id,
+			locale,
+			name,
+			created_at,
+			updated_at
+		) VALUES (
+			:category_id,
+			:locale,
+			:name,
+			:created_at,
+			:updated_at
+		)
+		ON CONFLICT (category_id, locale) DO UPDATE SET
+			name = EXCLUDED.name,
+			updated_at = EXCLUDED.updated_at
+	`
+	if _, err := r.db.NamedExecContext(ctx, query, t.Model()); err != nil {
+		return fault.New("failed to save category translation", fault.WithError(err))
+	}
+
+	return nil
+}
+
+func (r repo) DeleteTranslation(ctx context.Context, categoryID, locale string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	res, err := r.db.ExecContext(
+		ctx,
+		"DELETE FROM category_translations WHERE category_id = $1 AND locale = $2",
+		categoryID,
+		locale,
+	)
+	if err != nil {
+		return false, fault.New("failed to delete category translation", fault.WithError(err))
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fault.New("failed to delete category translation", fault.WithError(err))
+	}
+
+	return affected == 1, nil
+}
@@ -0,0 +1,194 @@
+package dataexportRepository
+
+import (
+	"context"
+	"msn/internal/infra/database/models"
+	"msn/internal/modules/dataexport"
+	"msn/pkg/common/fault"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type dataExportRepository struct {
+	db *sqlx.DB
+}
+
+func NewRepo(db *sqlx.DB) dataexport.Repository {
+	return &dataExportRepository{db: db}
+}
+
+func (r *dataExportRepository) Create(ctx context.Context, export *dataexport.Export) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	query := `
+		INSERT INTO data_exports (
+			id,
+			user_id,
+			status,
+			object_name,
+			attempts,
+			started_at,
+			completed_at,
+			expires_at,
+			created_at,
+			updated_at
+		) VALUES (
+			:id,
+			:user_id,
+			:status,
+			:object_name,
+			:attempts,
+			:started_at,
+			:completed_at,
+			:expires_at,
+			:created_at,
+			:updated_at
+		)
+	`
+
+	_, err := r.db.NamedExecContext(ctx, query, export.ToModel())
+	if err != nil {
+		return fault.New("failed to insert data export", fault.WithError(err))
+	}
+
+	return nil
+}
+
+func (r *dataExportRepository) Update(ctx context.Context, export *dataexport.Export) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	query := `
+		UPDATE data_exports SET
+			status = :status,
+			object_name = :object_name,
+			completed_at = :completed_at,
+			expires_at = :expires_at,
+			notified_at = :notified_at,
+			updated_at = :updated_at
+		WHERE id = :id
+	`
+
+	_, err := r.db.NamedExecContext(ctx, query, export.ToModel())
+	if err != nil {
+		return fault.New("failed to update data export", fault.WithError(err))
+	}
+
+	return nil
+}
+
+func (r *dataExportRepository) ClaimPending(ctx context.Context, staleBefore time.Time, limit int) ([]*dataexport.Export, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	_, err := r.db.ExecContext(
+		ctx,
+		`
+		UPDATE data_exports SET status = 'failed', updated_at = NOW()
+		WHERE status = 'processing' AND started_at < $1 AND attempts >= $2
+		`,
+		staleBefore,
+		dataexport.MaxAttempts,
+	)
+	if err != nil {
+		return nil, fault.New("failed to fail stale data exports", fault.WithError(err))
+	}
+
+	var exportModels []models.DataExport
+	err = r.db.SelectContext(
+		ctx,
+		&exportModels,
+		`
+		UPDATE data_exports SET
+			status = 'processing',
+			attempts = attempts + 1,
+			started_at = NOW(),
+			updated_at = NOW()
+		WHERE id IN (
+			SELECT id FROM data_exports
+			WHERE status = 'pending'
+				OR (status = 'processing' AND started_at < $1 AND attempts < $3)
+			ORDER BY created_at
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *
+		`,
+		staleBefore,
+		limit,
+		dataexport.MaxAttempts,
+	)
+	if err != nil {
+		return nil, fault.New("failed to claim pending data exports", fault.WithError(err))
+	}
+
+	exports := make([]*dataexport.Export, len(exportModels))
+	for i, m := range exportModels {
+		exports[i] = dataexport.NewFromModel(m)
+	}
+
+	return exports, nil
+}
+
+func (r *dataExportRepository) ClaimUnnotified(ctx context.Context, staleBefore time.Time, limit int) ([]*dataexport.Export, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	var exportModels []models.DataExport
+	err := r.db.SelectContext(
+		ctx,
+		&exportModels,
+		`
+		UPDATE data_exports SET updated_at = NOW()
+		WHERE id IN (
+			SELECT id FROM data_exports
+			WHERE status = 'ready'
+				AND notified_at IS NULL
+				AND expires_at > NOW()
+				AND updated_at < $1
+			ORDER BY completed_at
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *
+		`,
+		staleBefore,
+		limit,
+	)
+	if err != nil {
+		return nil, fault.New("failed to claim unnotified data exports", fault.WithError(err))
+	}
+
+	exports := make([]*dataexport.Export, len(exportModels))
+	for i, m := range exportModels {
+		exports[i] = dataexport.NewFromModel(m)
+	}
+
+	return exports, nil
+}
+
+func (r *dataExportRepository) GetExpired(ctx context.Context, now time.Time, limit int) ([]*dataexport.Export, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	var exportModels []models.DataExport
+	err := r.db.SelectContext(
+		ctx,
+		&exportModels,
+		"SELECT * FROM data_exports WHERE status = 'ready' AND expires_at < $1 ORDER BY expires_at LIMIT $2",
+		now,
+		limit,
+	)
+	if err != nil {
+		return nil, fault.New("failed to retrieve expired data exports", fault.WithError(err))
+	}
+
+	exports := make([]*dataexport.Export, len(exportModels))
+	for i, m := range exportModels {
+		exports[i] = dataexport.NewFromModel(m)
+	}
+
+	return exports, nil
+}
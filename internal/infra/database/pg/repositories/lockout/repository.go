@@ -0,0 +1,93 @@
+package lockoutRepository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"msn/internal/infra/database/models"
+	"msn/internal/modules/lockout"
+	"msn/pkg/common/fault"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type lockoutRepository struct {
+	db *sqlx.DB
+}
+
+func NewRepo(db *sqlx.DB) lockout.Store {
+	return &lockoutRepository{db: db}
+}
+
+func (r *lockoutRepository) Get(ctx context.Context, key string) (*lockout.Attempts, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	var attemptModel models.LoginAttempt
+	err := r.db.GetContext(ctx, &attemptModel, "SELECT * FROM login_attempts WHERE key = $1", key)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fault.New("failed to retrieve login attempts", fault.WithError(err))
+	}
+
+	return lockout.NewFromModel(attemptModel), nil
+}
+
+// Increment bumps the counter in a single statement so concurrent failures
+// are never lost. A counter whose window has elapsed starts over at 1.
+func (r *lockoutRepository) Increment(ctx context.Context, key string, window time.Duration) (*lockout.Attempts, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	query := `
+		INSERT INTO login_attempts (key, failures, first_failure_at, last_failure_at)
+		VALUES ($1, 1, $2, $2)
+		ON CONFLICT (key) DO UPDATE SET
+			failures = CASE
+				WHEN login_attempts.first_failure_at <= $3 THEN 1
+				ELSE login_attempts.failures + 1
+			END,
+			first_failure_at = CASE
+				WHEN login_attempts.first_failure_at <= $3 THEN $2
+				ELSE login_attempts.first_failure_at
+			END,
+			last_failure_at = $2
+		RETURNING *
+	`
+
+	var attemptModel models.LoginAttempt
+	err := r.db.GetContext(ctx, &attemptModel, query, key, now, now.Add(-window))
+	if err != nil {
+		return nil, fault.New("failed to increment login attempts", fault.WithError(err))
+	}
+
+	return lockout.NewFromModel(attemptModel), nil
+}
+
+func (r *lockoutRepository) Lock(ctx context.Context, key string, until time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, "UPDATE login_attempts SET locked_until = $1 WHERE key = $2", until, key)
+	if err != nil {
+		return fault.New("failed to lock login attempts", fault.WithError(err))
+	}
+
+	return nil
+}
+
+func (r *lockoutRepository) Reset(ctx context.Context, key string) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, "DELETE FROM login_attempts WHERE key = $1", key)
+	if err != nil {
+		return fault.New("failed to reset login attempts", fault.WithError(err))
+	}
+
+	return nil
+}
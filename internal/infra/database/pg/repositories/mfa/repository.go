@@ -0,0 +1,201 @@
+package mfaRepository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"msn/internal/infra/database/models"
+	"msn/internal/modules/mfa"
+	"msn/pkg/common/fault"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type mfaRepository struct {
+	db *sqlx.DB
+}
+
+func NewRepo(db *sqlx.DB) mfa.Repository {
+	return &mfaRepository{db: db}
+}
+
+func (r *mfaRepository) GetByUserID(ctx context.Context, userID string) (*mfa.Enrollment, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	var enrollmentModel models.UserMFA
+	err := r.db.GetContext(ctx, &enrollmentModel, "SELECT * FROM user_mfa WHERE user_id = $1", userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fault.New("failed to retrieve mfa enrollment", fault.WithError(err))
+	}
+
+	return mfa.NewFromModel(enrollmentModel), nil
+}
+
+func (r *mfaRepository) Save(ctx context.Context, enrollment *mfa.Enrollment) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	query := `
+		INSERT INTO user_mfa (
+			user_id,
+			secret,
+			last_used_step,
+			enabled_at,
+			created_at,
+			updated_at
+		) VALUES (
+			:user_id,
+			:secret,
+			:last_used_step,
+			:enabled_at,
+			:created_at,
+			:updated_at
+		)
+		ON CONFLICT (user_id) DO UPDATE SET
+			secret = EXCLUDED.secret,
+			last_used_step = EXCLUDED.last_used_step,
+			enabled_at = EXCLUDED.enabled_at,
+			created_at = EXCLUDED.created_at,
+			updated_at = EXCLUDED.updated_at
+	`
+
+	_, err := r.db.NamedExecContext(ctx, query, enrollment.ToModel())
+	if err != nil {
+		return fault.New("failed to save mfa enrollment", fault.WithError(err))
+	}
+
+	return nil
+}
+
+// UseStep stores step as the last accepted one, unless an equal or later
+// step was stored concurrently. It reports whether the step was accepted.
+func (r *mfaRepository) UseStep(ctx context.Context, userID string, step int64) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	res, err := r.db.ExecContext(
+		ctx,
+		"UPDATE user_mfa SET last_used_step = $1, updated_at = NOW() WHERE user_id = $2 AND last_used_step < $1",
+		step,
+		userID,
+	)
+	if err != nil {
+		return false, fault.New("failed to update mfa step", fault.WithError(err))
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fault.New("failed to update mfa step", fault.WithError(err))
+	}
+
+	return affected == 1, nil
+}
+
+func (r *mfaRepository) Delete(ctx context.Context, userID string) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fault.New(
+			"failed to begin transaction",
+			fault.WithTag(fault.DB_TRANSACTION),
+			fault.WithError(err),
+		)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM mfa_recovery_codes WHERE user_id = $1", userID); err != nil {
+		return fault.New("failed to delete recovery codes", fault.WithError(err))
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM user_mfa WHERE user_id = $1", userID); err != nil {
+		return fault.New("failed to delete mfa enrollment", fault.WithError(err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fault.New(
+			"failed to commit mfa removal",
+			fault.WithTag(fault.DB_TRANSACTION),
+			fault.WithError(err),
+		)
+	}
+
+	return nil
+}
+
+func (r *mfaRepository) ReplaceRecoveryCodes(ctx context.Context, userID string, codes []*mfa.RecoveryCode) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fault.New(
+			"failed to begin transaction",
+			fault.WithTag(fault.DB_TRANSACTION),
+			fault.WithError(err),
+		)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM mfa_recovery_codes WHERE user_id = $1", userID); err != nil {
+		return fault.New("failed to delete recovery codes", fault.WithError(err))
+	}
+
+	query := `
+		INSERT INTO mfa_recovery_codes (
+			id,
+			user_id,
+			code_hash,
+			used_at,
+			created_at
+		) VALUES (
+			:id,
+			:user_id,
+			:code_hash,
+			:used_at,
+			:created_at
+		)
+	`
+	for _, code := range codes {
+		if _, err := tx.NamedExecContext(ctx, query, code.ToModel()); err != nil {
+			return fault.New("failed to insert recovery code", fault.WithError(err))
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fault.New(
+			"failed to commit recovery codes",
+			fault.WithTag(fault.DB_TRANSACTION),
+			fault.WithError(err),
+		)
+	}
+
+	return nil
+}
+
+func (r *mfaRepository) UseRecoveryCode(ctx context.Context, userID, codeHash string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	res, err := r.db.ExecContext(
+		ctx,
+		"UPDATE mfa_recovery_codes SET used_at = NOW() WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL",
+		userID,
+		codeHash,
+	)
+	if err != nil {
+		return false, fault.New("failed to use recovery code", fault.WithError(err))
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fault.New("failed to use recovery code", fault.WithError(err))
+	}
+
+	return affected == 1, nil
+}
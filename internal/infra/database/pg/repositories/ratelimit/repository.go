@@ -0,0 +1,111 @@
+package ratelimitRepository
+
+import (
+	"context"
+	"msn/internal/infra/database/models"
+	"msn/internal/modules/ratelimit"
+	"msn/pkg/common/fault"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// cleanupInterval is how often Take also deletes buckets that refilled.
+const cleanupInterval = 5 * time.Minute
+
+type ratelimitRepository struct {
+	db *sqlx.DB
+
+	mu          sync.Mutex
+	lastCleanup time.Time
+}
+
+func NewRepo(db *sqlx.DB) ratelimit.Store {
+	return &ratelimitRepository{db: db}
+}
+
+// Take locks the bucket row for the duration of the transaction so every
+// instance sharing the database spends from the same bucket.
+func (r *ratelimitRepository) Take(ctx context.Context, key string, limit ratelimit.Limit) (*ratelimit.Result, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	r.cleanup(ctx, now)
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fault.New(
+			"failed to begin transaction",
+			fault.WithTag(fault.DB_TRANSACTION),
+			fault.WithError(err),
+		)
+	}
+	defer tx.Rollback()
+
+	bucket := ratelimit.NewBucket(key, limit, now)
+	_, err = tx.ExecContext(
+		ctx,
+		`
+		INSERT INTO rate_limit_buckets (key, tokens, updated_at, expires_at)
+		VALUES ($1, $2, $3, $3)
+		ON CONFLICT (key) DO NOTHING
+		`,
+		key,
+		bucket.Tokens,
+		now,
+	)
+	if err != nil {
+		return nil, fault.New("failed to create rate limit bucket", fault.WithError(err))
+	}
+
+	var bucketModel models.RateLimitBucket
+	err = tx.GetContext(ctx, &bucketModel, "SELECT * FROM rate_limit_buckets WHERE key = $1 FOR UPDATE", key)
+	if err != nil {
+		return nil, fault.New("failed to retrieve rate limit bucket", fault.WithError(err))
+	}
+
+	if bucketModel.ExpiresAt.After(now) {
+		bucket.Tokens = bucketModel.Tokens
+		bucket.UpdatedAt = bucketModel.UpdatedAt
+	}
+
+	res := bucket.Take(limit, now)
+
+	_, err = tx.ExecContext(
+		ctx,
+		"UPDATE rate_limit_buckets SET tokens = $1, updated_at = $2, expires_at = $3 WHERE key = $4",
+		bucket.Tokens,
+		bucket.UpdatedAt,
+		bucket.FullAt(limit),
+		key,
+	)
+	if err != nil {
+		return nil, fault.New("failed to update rate limit bucket", fault.WithError(err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fault.New(
+			"failed to commit rate limit bucket",
+			fault.WithTag(fault.DB_TRANSACTION),
+			fault.WithError(err),
+		)
+	}
+
+	return &res, nil
+}
+
+// cleanup drops refilled buckets at most once per cleanupInterval. Errors
+// are ignored; the rows are retried on the next run.
+func (r *ratelimitRepository) cleanup(ctx context.Context, now time.Time) {
+	r.mu.Lock()
+	if now.Sub(r.lastCleanup) < cleanupInterval {
+		r.mu.Unlock()
+		return
+	}
+	r.lastCleanup = now
+	r.mu.Unlock()
+
+	_, _ = r.db.ExecContext(ctx, "DELETE FROM rate_limit_buckets WHERE expires_at < $1", now)
+}
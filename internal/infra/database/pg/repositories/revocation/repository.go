@@ -0,0 +1,83 @@
+package revocationRepository
+
+import (
+	"context"
+	"msn/internal/modules/revocation"
+	"msn/pkg/common/fault"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// cleanupInterval is how often Add also deletes expired entries.
+const cleanupInterval = 5 * time.Minute
+
+type revocationRepository struct {
+	db *sqlx.DB
+
+	mu          sync.Mutex
+	lastCleanup time.Time
+}
+
+func NewRepo(db *sqlx.DB) revocation.Store {
+	return &revocationRepository{db: db}
+}
+
+func (r *revocationRepository) Add(ctx context.Context, entry revocation.Entry) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	r.cleanup(ctx, time.Now())
+
+	_, err := r.db.ExecContext(
+		ctx,
+		`
+		INSERT INTO access_token_denylist (key, expires_at)
+		VALUES ($1, $2)
+		ON CONFLICT (key) DO UPDATE SET
+			expires_at = GREATEST(access_token_denylist.expires_at, EXCLUDED.expires_at)
+		`,
+		entry.Key,
+		entry.ExpiresAt,
+	)
+	if err != nil {
+		return fault.New("failed to add denylist entry", fault.WithError(err))
+	}
+
+	return nil
+}
+
+func (r *revocationRepository) Contains(ctx context.Context, keys ...string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	var found bool
+	err := r.db.GetContext(
+		ctx,
+		&found,
+		"SELECT EXISTS (SELECT 1 FROM access_token_denylist WHERE key = ANY($1) AND expires_at > $2)",
+		pq.Array(keys),
+		time.Now(),
+	)
+	if err != nil {
+		return false, fault.New("failed to check denylist", fault.WithError(err))
+	}
+
+	return found, nil
+}
+
+// cleanup drops expired entries at most once per cleanupInterval. Errors are
+// ignored; the rows are retried on the next run.
+func (r *revocationRepository) cleanup(ctx context.Context, now time.Time) {
+	r.mu.Lock()
+	if now.Sub(r.lastCleanup) < cleanupInterval {
+		r.mu.Unlock()
+		return
+	}
+	r.lastCleanup = now
+	r.mu.Unlock()
+
+	_, _ = r.db.ExecContext(ctx, "DELETE FROM access_token_denylist WHERE expires_at < $1", now)
+}
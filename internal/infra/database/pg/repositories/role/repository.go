@@ -2,11 +2,15 @@ package role
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"msn/internal/infra/database/models"
 	"msn/internal/modules/role"
+	"msn/pkg/common/fault"
 	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 type roleRepo struct {
@@ -17,15 +21,57 @@ func NewRepo(db *sqlx.DB) role.Repository {
 	return &roleRepo{db: db}
 }
 
+type roleWithPermissions struct {
+	models.Role
+	Permissions pq.StringArray `db:"permissions"`
+}
+
 func (u roleRepo) GetRoleByName(ctx context.Context, userRoleName string) (*role.Role, error) {
+	return u.get(ctx, "r.name = $1", userRoleName)
+}
+
+func (u roleRepo) GetRoleByID(ctx context.Context, roleID string) (*role.Role, error) {
+	return u.get(ctx, "r.id = $1", roleID)
+}
+
+func (u roleRepo) get(ctx context.Context, where string, args ...any) (*role.Role, error) {
 	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
 	defer cancel()
 
-	var modelRole models.Role
-	query := `SELECT * FROM roles WHERE name = $1 AND deleted_at IS NULL`
-	err := u.db.GetContext(ctx, &modelRole, query, userRoleName)
+	var out roleWithPermissions
+	query := `
+		SELECT
+			r.*,
+			COALESCE(array_agg(rp.permission) FILTER (WHERE rp.permission IS NOT NULL), '{}') AS permissions
+		FROM roles r
+		LEFT JOIN role_permissions rp ON rp.role_id = r.id
+		WHERE r.deleted_at IS NULL AND ` + where + `
+		GROUP BY r.id
+	`
+	err := u.db.GetContext(ctx, &out, query, args...)
 	if err != nil {
-		return nil, err
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fault.New("failed to get role", fault.WithError(err))
 	}
-	return role.NewFromModel(modelRole), nil
+
+	return role.NewFromModel(out.Role, out.Permissions), nil
+}
+
+func (u roleRepo) AssignToUser(ctx context.Context, userID, roleID string) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	_, err := u.db.ExecContext(
+		ctx,
+		"UPDATE users SET role_id = $1, updated_at = NOW(), version = version + 1 WHERE id = $2",
+		roleID,
+		userID,
+	)
+	if err != nil {
+		return fault.New("failed to assign role", fault.WithError(err))
+	}
+
+	return nil
 }
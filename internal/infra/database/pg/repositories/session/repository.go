@@ -43,7 +43,7 @@ func (r *sessionRepository) GetAllByUserID(ctx context.Context, userID string) (
 	return result, nil
 }
 
-func (r *sessionRepository) GetActiveByUserID(ctx context.Context, userID string) (*session.Session, error) {
+func (r *sessionRepository) GetByID(ctx context.Context, ID string) (*session.Session, error) {
 	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
 	defer cancel()
 
@@ -51,15 +51,15 @@ func (r *sessionRepository) GetActiveByUserID(ctx context.Context, userID string
 	err := r.db.GetContext(
 		ctx,
 		&sessionModel,
-		"SELECT * FROM sessions WHERE user_id = $1 AND active = true LIMIT 1",
-		userID,
+		"SELECT * FROM sessions WHERE id = $1",
+		ID,
 	)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil
 		}
 		return nil, fault.New(
-			"failed to retrieve active session",
+			"failed to retrieve session by ID",
 			fault.WithError(err),
 		)
 	}
@@ -88,6 +88,32 @@ func (r *sessionRepository) GetByJTI(ctx context.Context, JTI string) (*session.
 	return session.NewFromModel(sessionModel), nil
 }
 
+func (r *sessionRepository) GetByRotatedJTI(ctx context.Context, JTI string) (*session.Session, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	var sessionModel models.Session
+	err := r.db.GetContext(
+		ctx,
+		&sessionModel,
+		`
+		SELECT s.*
+		FROM refresh_token_rotations rtr
+		JOIN sessions s ON s.id = rtr.session_id
+		WHERE rtr.jti = $1
+		`,
+		JTI,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fault.New("failed to retrieve session by rotated JTI", fault.WithError(err))
+	}
+
+	return session.NewFromModel(sessionModel), nil
+}
+
 func (r *sessionRepository) Create(ctx context.Context, session *session.Session) error {
 	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
 	defer cancel()
@@ -100,6 +126,10 @@ func (r *sessionRepository) Create(ctx context.Context, session *session.Session
 			user_id,
 			jti,
 			active,
+			device_label,
+			user_agent,
+			ip_address,
+			last_seen_at,
 			created_at,
 			updated_at,
 			expires_at
@@ -108,6 +138,10 @@ func (r *sessionRepository) Create(ctx context.Context, session *session.Session
 			:user_id,
 			:jti,
 			:active,
+			:device_label,
+			:user_agent,
+			:ip_address,
+			:last_seen_at,
 			:created_at,
 			:updated_at,
 			:expires_at
@@ -132,6 +166,9 @@ func (r *sessionRepository) Update(ctx context.Context, session *session.Session
 		SET
 			active = :active,
 			jti = :jti,
+			user_agent = :user_agent,
+			ip_address = :ip_address,
+			last_seen_at = :last_seen_at,
 			updated_at = :updated_at
 		WHERE id = :id
 	`
@@ -155,3 +192,72 @@ func (r sessionRepository) DeactivateAll(ctx context.Context, userId string) err
 
 	return nil
 }
+
+func (r *sessionRepository) Rotate(ctx context.Context, session *session.Session, previousJTI string) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fault.New(
+			"failed to begin transaction",
+			fault.WithTag(fault.DB_TRANSACTION),
+			fault.WithError(err),
+		)
+	}
+	defer tx.Rollback()
+
+	modelSession := session.ToModel()
+	res, err := tx.ExecContext(
+		ctx,
+		`
+		UPDATE sessions
+		SET
+			jti = $1,
+			user_agent = $2,
+			ip_address = $3,
+			last_seen_at = $4,
+			updated_at = $5
+		WHERE id = $6 AND jti = $7 AND active = true
+		`,
+		modelSession.JTI,
+		modelSession.UserAgent,
+		modelSession.IPAddress,
+		modelSession.LastSeenAt,
+		modelSession.UpdatedAt,
+		modelSession.ID,
+		previousJTI,
+	)
+	if err != nil {
+		return fault.New("failed to rotate session", fault.WithError(err))
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fault.New("failed to rotate session", fault.WithError(err))
+	}
+	if affected == 0 {
+		return fault.New("session was rotated concurrently", fault.WithTag(fault.CONFLICT))
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		"INSERT INTO refresh_token_rotations (jti, session_id, rotated_at) VALUES ($1, $2, $3)",
+		previousJTI,
+		modelSession.ID,
+		modelSession.UpdatedAt,
+	)
+	if err != nil {
+		return fault.New("failed to record rotated JTI", fault.WithError(err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fault.New(
+			"failed to commit session rotation",
+			fault.WithTag(fault.DB_TRANSACTION),
+			fault.WithError(err),
+		)
+	}
+
+	return nil
+}
@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 type userRepo struct {
@@ -22,63 +23,222 @@ func NewRepo(db *sqlx.DB) user.UserRepository {
 	return &userRepo{db: db}
 }
 
-// func (r repo) Update(ctx context.Context, user model.User) error {
-// 	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
-// 	defer cancel()
-//
-// 	var query = `
-// 		UPDATE users
-// 		SET
-// 			name = :name,
-// 			username = :username,
-// 			email = :email,
-// 			password = :password,
-// 			avatar_url = :avatar_url,
-// 			enabled = :enabled,
-// 			locked = :locked,
-// 			updated = :updated
-// 		WHERE id = :id
-// 	`
-//
-// 	_, err := r.db.NamedExecContext(ctx, query, user)
-// 	if err != nil {
-// 		return fault.New("failed to update user", fault.WithError(err))
-// 	}
-//
-// 	return nil
-// }
+func (r userRepo) Update(ctx context.Context, user *user.User) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	query := `
+		UPDATE users SET
+			name = :name,
+			avatar_url = :avatar_url,
+			subcategory_id = :subcategory_id,
+			updated_at = :updated_at,
+			version = version + 1
+		WHERE id = :id AND version = :version AND deleted_at IS NULL
+	`
+
+	res, err := r.db.NamedExecContext(ctx, query, user.ToModel())
+	if err != nil {
+		return false, fault.New("failed to update user", fault.WithError(err))
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fault.New("failed to update user", fault.WithError(err))
+	}
+
+	return affected == 1, nil
+}
+
+func (r userRepo) ChangePassword(ctx context.Context, userID, hashedPassword string, keep int) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fault.New(
+			"failed to begin transaction",
+			fault.WithTag(fault.DB_TRANSACTION),
+			fault.WithError(err),
+		)
+	}
+	defer tx.Rollback()
+
+	if keep > 0 {
+		_, err = tx.ExecContext(
+			ctx,
+			"INSERT INTO password_history (user_id, password_hash) SELECT id, password FROM users WHERE id = $1",
+			userID,
+		)
+		if err != nil {
+			return fault.New("failed to save password history", fault.WithError(err))
+		}
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		"UPDATE users SET password = $1, updated_at = NOW() WHERE id = $2",
+		hashedPassword,
+		userID,
+	)
+	if err != nil {
+		return fault.New("failed to update user password", fault.WithError(err))
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		`
+		DELETE FROM password_history
+		WHERE user_id = $1 AND id NOT IN (
+			SELECT id FROM password_history
+			WHERE user_id = $1
+			ORDER BY created_at DESC, id DESC
+			LIMIT $2
+		)
+		`,
+		userID,
+		keep,
+	)
+	if err != nil {
+		return fault.New("failed to prune password history", fault.WithError(err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fault.New(
+			"failed to commit password change",
+			fault.WithTag(fault.DB_TRANSACTION),
+			fault.WithError(err),
+		)
+	}
+
+	return nil
+}
+
+func (r userRepo) GetPasswordHistory(ctx context.Context, userID string, limit int) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	hashes := []string{}
+	err := r.db.SelectContext(
+		ctx,
+		&hashes,
+		"SELECT password_hash FROM password_history WHERE user_id = $1 ORDER BY created_at DESC, id DESC LIMIT $2",
+		userID,
+		limit,
+	)
+	if err != nil {
+		return nil, fault.New("failed to retrieve password history", fault.WithError(err))
+	}
+
+	return hashes, nil
+}
+
+func (r userRepo) SavePendingEmail(ctx context.Context, user *user.User) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	query := `
+		UPDATE users SET
+			pending_email = :pending_email,
+			updated_at = :updated_at
+		WHERE id = :id AND deleted_at IS NULL
+	`
+
+	_, err := r.db.NamedExecContext(ctx, query, user.ToModel())
+	if err != nil {
+		return fault.New("failed to save pending email", fault.WithError(err))
+	}
+
+	return nil
+}
+
+func (r userRepo) ConfirmPendingEmail(ctx context.Context, userID string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	query := `
+		UPDATE users SET
+			email = pending_email,
+			pending_email = NULL,
+			verified_at = COALESCE(verified_at, NOW()),
+			updated_at = NOW(),
+			version = version + 1
+		WHERE id = $1 AND pending_email IS NOT NULL AND deleted_at IS NULL
+	`
+
+	res, err := r.db.ExecContext(ctx, query, userID)
+	if err != nil {
+		return false, fault.New("failed to confirm pending email", fault.WithError(err))
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fault.New("failed to confirm pending email", fault.WithError(err))
+	}
+
+	return affected == 1, nil
+}
+
+func (r userRepo) MarkVerified(ctx context.Context, userID string, verifiedAt time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	_, err := r.db.ExecContext(
+		ctx,
+		"UPDATE users SET verified_at = $1, updated_at = NOW() WHERE id = $2 AND verified_at IS NULL",
+		verifiedAt,
+		userID,
+	)
+	if err != nil {
+		return fault.New("failed to mark user as verified", fault.WithError(err))
+	}
+
+	return nil
+}
 
 func (r userRepo) GetEnrichedByEmail(ctx context.Context, email string) (*dto.EnrichedUserResponse, error) {
+	return r.getEnriched(ctx, "u.email = $1", email)
+}
+
+func (r userRepo) GetEnrichedByID(ctx context.Context, userID string) (*dto.EnrichedUserResponse, error) {
+	return r.getEnriched(ctx, "u.id = $1", userID)
+}
+
+func (r userRepo) getEnriched(ctx context.Context, where string, args ...any) (*dto.EnrichedUserResponse, error) {
 	var out struct {
-		ID              string     `db:"id"`
-		Name            string     `db:"name"`
-		Email           string     `db:"email"`
-		AvatarURL       string     `db:"avatar_url"`
-		HashedPassword  string     `db:"password"`
-		CreatedAt       time.Time  `db:"created_at"`
-		DeletedAt       *time.Time `db:"deleted_at"`
-		RoleID          string     `db:"role_id"`
-		RoleName        string     `db:"role_name"`
-		SubcategoryID   *string    `db:"subcategory_id"`
-		SubcategoryName *string    `db:"subcategory_name"`
-		CategoryID      *string    `db:"category_id"`
-		CategoryName    *string    `db:"category_name"`
-		CategoryIcon    *string    `db:"category_icon"`
+		ID              string         `db:"id"`
+		Name            string         `db:"name"`
+		Email           string         `db:"email"`
+		PendingEmail    *string        `db:"pending_email"`
+		AvatarURL       string         `db:"avatar_url"`
+		HashedPassword  string         `db:"password"`
+		VerifiedAt      *time.Time     `db:"verified_at"`
+		CreatedAt       time.Time      `db:"created_at"`
+		DeletedAt       *time.Time     `db:"deleted_at"`
+		Version         int            `db:"version"`
+		RoleID          string         `db:"role_id"`
+		RoleName        string         `db:"role_name"`
+		RolePermissions pq.StringArray `db:"role_permissions"`
+		SubcategoryID   *string        `db:"subcategory_id"`
+		SubcategoryName *string        `db:"subcategory_name"`
+		CategoryID      *string        `db:"category_id"`
+		CategoryName    *string        `db:"category_name"`
+		CategoryIcon    *string        `db:"category_icon"`
 	}
 
 	query := `
     SELECT
-      u.id, u.name, u.email, u.avatar_url, u.password, u.created_at, u.deleted_at,
+      u.id, u.name, u.email, u.pending_email, u.avatar_url, u.password, u.verified_at, u.created_at, u.deleted_at, u.version,
       r.id  	AS role_id,          r.name AS role_name,
+      COALESCE((SELECT array_agg(rp.permission) FROM role_permissions rp WHERE rp.role_id = r.id), '{}') AS role_permissions,
       s.id    AS subcategory_id,   s.name AS subcategory_name,
-      c.id    AS category_id,      c.name AS category_name, c.icon as category_icon
+      c.id    AS category_id,      c.name AS category_name, COALESCE(c.icon, '') as category_icon
     FROM users u
     LEFT JOIN roles r    ON r.id = u.role_id
-    LEFT JOIN subcategories s  ON s.id  = u.subcategory_id
-    LEFT JOIN categories c     ON c.id  = s.category_id
-    WHERE u.email = $1
-    `
-	err := r.db.GetContext(ctx, &out, query, email)
+    LEFT JOIN categories s     ON s.id  = u.subcategory_id
+    LEFT JOIN categories c     ON c.id  = split_part(s.path, '/', 2)
+    WHERE u.deleted_at IS NULL AND ` + where
+	err := r.db.GetContext(ctx, &out, query, args...)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil
@@ -90,13 +250,17 @@ func (r userRepo) GetEnrichedByEmail(ctx context.Context, email string) (*dto.En
 		ID:             out.ID,
 		Name:           out.Name,
 		Email:          out.Email,
+		PendingEmail:   out.PendingEmail,
 		AvatarURL:      out.AvatarURL,
 		HashedPassword: out.HashedPassword,
+		VerifiedAt:     out.VerifiedAt,
 		CreatedAt:      out.CreatedAt,
 		DeletedAt:      out.DeletedAt,
+		Version:        out.Version,
 		Role: &dto.Role{
-			ID:   out.RoleID,
-			Name: out.RoleName,
+			ID:          out.RoleID,
+			Name:        out.RoleName,
+			Permissions: out.RolePermissions,
 		},
 	}
 
@@ -115,7 +279,7 @@ func (r userRepo) GetByEmail(ctx context.Context, email string) (*user.User, err
 	defer cancel()
 
 	var modelUser models.User
-	err := r.db.GetContext(ctx, &modelUser, "SELECT * FROM users WHERE email = $1", email)
+	err := r.db.GetContext(ctx, &modelUser, "SELECT * FROM users WHERE email = $1 AND deleted_at IS NULL", email)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil
@@ -136,7 +300,7 @@ func (r userRepo) GetByID(ctx context.Context, userId string) (*user.User, error
 	defer cancel()
 
 	var modelUser models.User
-	err := r.db.GetContext(ctx, &modelUser, "SELECT * FROM users WHERE id = $1 LIMIT 1", userId)
+	err := r.db.GetContext(ctx, &modelUser, "SELECT * FROM users WHERE id = $1 AND deleted_at IS NULL LIMIT 1", userId)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil
@@ -152,6 +316,190 @@ func (r userRepo) GetByID(ctx context.Context, userId string) (*user.User, error
 	return user, nil
 }
 
+func (r userRepo) GetDeletedByID(ctx context.Context, userID string) (*user.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	var modelUser models.User
+	err := r.db.GetContext(ctx, &modelUser, "SELECT * FROM users WHERE id = $1 AND deleted_at IS NOT NULL LIMIT 1", userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fault.New("failed to retrieve deleted user", fault.WithError(err))
+	}
+
+	return user.NewFromModel(modelUser)
+}
+
+func (r userRepo) GetPendingAnonymization(ctx context.Context, deletedBefore time.Time, limit int) ([]*user.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	var modelUsers []models.User
+	err := r.db.SelectContext(
+		ctx,
+		&modelUsers,
+		`
+		SELECT * FROM users
+		WHERE deleted_at IS NOT NULL
+			AND (
+				(anonymized_at IS NULL AND deleted_at < $1)
+				OR (anonymized_at IS NOT NULL AND avatar_url <> '')
+			)
+		ORDER BY deleted_at
+		LIMIT $2
+		`,
+		deletedBefore,
+		limit,
+	)
+	if err != nil {
+		return nil, fault.New("failed to retrieve users pending anonymization", fault.WithError(err))
+	}
+
+	users := make([]*user.User, 0, len(modelUsers))
+	for _, m := range modelUsers {
+		u, err := user.NewFromModel(m)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+
+	return users, nil
+}
+
+func (r userRepo) Delete(ctx context.Context, user *user.User) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	query := `
+		UPDATE users SET
+			pending_email = :pending_email,
+			deleted_at = :deleted_at,
+			updated_at = :updated_at,
+			version = version + 1
+		WHERE id = :id AND deleted_at IS NULL
+	`
+
+	res, err := r.db.NamedExecContext(ctx, query, user.ToModel())
+	if err != nil {
+		return false, fault.New("failed to delete user", fault.WithError(err))
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fault.New("failed to delete user", fault.WithError(err))
+	}
+
+	return affected == 1, nil
+}
+
+func (r userRepo) Restore(ctx context.Context, user *user.User, deletedAfter time.Time) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	res, err := r.db.ExecContext(
+		ctx,
+		`
+		UPDATE users SET
+			deleted_at = NULL,
+			updated_at = NOW(),
+			version = version + 1
+		WHERE id = $1 AND deleted_at >= $2 AND anonymized_at IS NULL
+		`,
+		user.ID(),
+		deletedAfter,
+	)
+	if err != nil {
+		return false, fault.New("failed to restore user", fault.WithError(err))
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fault.New("failed to restore user", fault.WithError(err))
+	}
+
+	return affected == 1, nil
+}
+
+func (r userRepo) Anonymize(ctx context.Context, userID string, deletedBefore time.Time) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, fault.New(
+			"failed to begin transaction",
+			fault.WithTag(fault.DB_TRANSACTION),
+			fault.WithError(err),
+		)
+	}
+	defer tx.Rollback()
+
+	// The email stays unique and well formed, so the row still loads and the
+	// address is free for a new account. Setting anonymized_at also stops
+	// any later restore.
+	res, err := tx.ExecContext(
+		ctx,
+		`
+		UPDATE users SET
+			name = 'deleted user',
+			email = id || '@deleted.invalid',
+			pending_email = NULL,
+			password = '',
+			updated_at = NOW(),
+			anonymized_at = NOW()
+		WHERE id = $1 AND deleted_at < $2 AND anonymized_at IS NULL
+		`,
+		userID,
+		deletedBefore,
+	)
+	if err != nil {
+		return false, fault.New("failed to anonymize user", fault.WithError(err))
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fault.New("failed to anonymize user", fault.WithError(err))
+	}
+	if affected != 1 {
+		return false, nil
+	}
+
+	for _, table := range []string{"sessions", "user_tokens", "password_history", "mfa_recovery_codes", "user_mfa"} {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM "+table+" WHERE user_id = $1", userID); err != nil {
+			return false, fault.New("failed to remove data of "+table, fault.WithError(err))
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fault.New(
+			"failed to commit user anonymization",
+			fault.WithTag(fault.DB_TRANSACTION),
+			fault.WithError(err),
+		)
+	}
+
+	return true, nil
+}
+
+func (r userRepo) ClearAvatar(ctx context.Context, userID string) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	_, err := r.db.ExecContext(
+		ctx,
+		"UPDATE users SET avatar_url = '', updated_at = NOW() WHERE id = $1 AND anonymized_at IS NOT NULL",
+		userID,
+	)
+	if err != nil {
+		return fault.New("failed to clear user avatar", fault.WithError(err))
+	}
+
+	return nil
+}
+
 func (r userRepo) Create(ctx context.Context, user *user.User) error {
 	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
 	defer cancel()
@@ -167,6 +515,7 @@ func (r userRepo) Create(ctx context.Context, user *user.User) error {
 			avatar_url,
 			role_id,
 			subcategory_id,
+			verified_at,
 			created_at,
 			updated_at,
 			deleted_at
@@ -178,6 +527,7 @@ func (r userRepo) Create(ctx context.Context, user *user.User) error {
 			:avatar_url,
 			:role_id,
 			:subcategory_id,
+			:verified_at,
 			:created_at,
 			:updated_at,
 			:deleted_at
@@ -214,11 +564,13 @@ func (r userRepo) GetProfessionalUsers(ctx context.Context) ([]*dto.Professional
 			u.avatar_url,
 			s.id as subcategory_id,
 			s."name" as subcategory_name,
-			s.category_id as category_id
+			split_part(s.path, '/', 2) as category_id
 		FROM users u
 		LEFT JOIN roles r ON r.id = u.role_id
-		LEFT JOIN subcategories s ON s.id = u.subcategory_id
-		WHERE r."name" = 'professional' AND u.deleted_at IS NULL;
+		JOIN categories s ON s.id = u.subcategory_id
+		WHERE r."name" = 'professional'
+			AND u.deleted_at IS NULL
+			AND u.verified_at IS NOT NULL;
 	`
 
 	err := r.db.SelectContext(ctx, &out, query)
@@ -0,0 +1,119 @@
+package usertokenRepository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"msn/internal/infra/database/models"
+	"msn/internal/modules/usertoken"
+	"msn/pkg/common/fault"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type userTokenRepository struct {
+	db *sqlx.DB
+}
+
+func NewRepo(db *sqlx.DB) usertoken.Repository {
+	return &userTokenRepository{db: db}
+}
+
+func (r *userTokenRepository) Create(ctx context.Context, token *usertoken.Token) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	query := `
+		INSERT INTO user_tokens (
+			id,
+			user_id,
+			purpose,
+			token_hash,
+			expires_at,
+			session_id,
+			used_at,
+			created_at
+		) VALUES (
+			:id,
+			:user_id,
+			:purpose,
+			:token_hash,
+			:expires_at,
+			:session_id,
+			:used_at,
+			:created_at
+		)
+	`
+
+	_, err := r.db.NamedExecContext(ctx, query, token.ToModel())
+	if err != nil {
+		return fault.New("failed to insert user token", fault.WithError(err))
+	}
+
+	return nil
+}
+
+func (r *userTokenRepository) Consume(ctx context.Context, id string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	res, err := r.db.ExecContext(
+		ctx,
+		"UPDATE user_tokens SET used_at = NOW() WHERE id = $1 AND used_at IS NULL AND expires_at > NOW()",
+		id,
+	)
+	if err != nil {
+		return false, fault.New("failed to consume user token", fault.WithError(err))
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fault.New("failed to consume user token", fault.WithError(err))
+	}
+
+	return affected == 1, nil
+}
+
+func (r *userTokenRepository) GetByHash(
+	ctx context.Context,
+	purpose usertoken.Purpose,
+	tokenHash string,
+) (*usertoken.Token, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	var tokenModel models.UserToken
+	err := r.db.GetContext(
+		ctx,
+		&tokenModel,
+		"SELECT * FROM user_tokens WHERE token_hash = $1 AND purpose = $2",
+		tokenHash,
+		string(purpose),
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fault.New("failed to retrieve user token", fault.WithError(err))
+	}
+
+	return usertoken.NewFromModel(tokenModel), nil
+}
+
+func (r *userTokenRepository) InvalidateAll(ctx context.Context, userID string, purpose usertoken.Purpose) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	_, err := r.db.ExecContext(
+		ctx,
+		"UPDATE user_tokens SET used_at = NOW() WHERE user_id = $1 AND purpose = $2 AND used_at IS NULL",
+		userID,
+		string(purpose),
+	)
+	if err != nil {
+		return fault.New("failed to invalidate user tokens", fault.WithError(err))
+	}
+
+	return nil
+}
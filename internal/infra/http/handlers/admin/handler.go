@@ -0,0 +1,121 @@
+package adminHandler
+
+import (
+	"msn/internal/infra/http/middlewares"
+	"msn/internal/modules/lockout"
+	"msn/internal/modules/role"
+	"msn/internal/modules/user"
+	"msn/pkg/common/dto"
+	"msn/pkg/common/fault"
+	"msn/pkg/utils/httputils"
+	"net/http"
+	"sync"
+
+	"github.com/go-chi/chi"
+)
+
+var (
+	instance *AdminHandler
+	Once     sync.Once
+)
+
+type AdminHandler struct {
+	userService    user.UserService
+	lockoutService lockout.Service
+	auth           *middlewares.AuthMiddleware
+	rateLimits     middlewares.RateLimits
+}
+
+func NewHandler(
+	userService user.UserService,
+	lockoutService lockout.Service,
+	auth *middlewares.AuthMiddleware,
+	rateLimits middlewares.RateLimits,
+) *AdminHandler {
+	Once.Do(
+		func() {
+			instance = &AdminHandler{
+				userService:    userService,
+				lockoutService: lockoutService,
+				auth:           auth,
+				rateLimits:     rateLimits,
+			}
+		},
+	)
+	return instance
+}
+
+func (h AdminHandler) RegisterRoutes(r *chi.Mux) {
+	r.Route(
+		"/api/v1/admin", func(r chi.Router) {
+			r.Use(h.auth.WithAuth, h.rateLimits.For(middlewares.RateLimitGroupUser))
+
+			r.With(middlewares.RequirePermission(role.PermissionRolesAssign)).Put("/users/{id}/role", h.handleAssignRole)
+			r.With(middlewares.RequirePermission(role.PermissionUsersRestore)).Post("/users/{id}/restore", h.handleRestoreUser)
+			r.With(middlewares.RequirePermission(role.PermissionUsersUnlock)).Post("/unlock", h.handleUnlock)
+		},
+	)
+}
+
+func (h AdminHandler) handleAssignRole(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var body dto.AssignRoleRequest
+	if err := httputils.ReadRequestBody(w, r, &body); err != nil {
+		fault.NewHTTPError(w, fault.NewBadRequest(err.Error()))
+		return
+	}
+	if body.Role == "" {
+		fault.NewHTTPError(w, fault.NewBadRequest("role is required"))
+		return
+	}
+
+	res, err := h.userService.AssignRole(ctx, chi.URLParam(r, "id"), body.Role)
+	if err != nil {
+		fault.NewHTTPError(w, err)
+		return
+	}
+
+	httputils.WriteJSON(w, http.StatusOK, res)
+}
+
+func (h AdminHandler) handleRestoreUser(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	res, err := h.userService.RestoreUser(ctx, chi.URLParam(r, "id"))
+	if err != nil {
+		fault.NewHTTPError(w, err)
+		return
+	}
+
+	httputils.WriteJSON(w, http.StatusOK, res)
+}
+
+func (h AdminHandler) handleUnlock(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var body dto.UnlockRequest
+	if err := httputils.ReadRequestBody(w, r, &body); err != nil {
+		fault.NewHTTPError(w, fault.NewBadRequest(err.Error()))
+		return
+	}
+	if body.Email == "" && body.IP == "" {
+		fault.NewHTTPError(w, fault.NewBadRequest("email or ip is required"))
+		return
+	}
+
+	if body.Email != "" {
+		if err := h.lockoutService.Unlock(ctx, body.Email); err != nil {
+			fault.NewHTTPError(w, fault.NewInternalServerError("failed to unlock account"))
+			return
+		}
+	}
+	if body.IP != "" {
+		if err := h.lockoutService.UnlockIP(ctx, body.IP); err != nil {
+			fault.NewHTTPError(w, fault.NewInternalServerError("failed to unlock ip"))
+			return
+		}
+	}
+
+	httputils.WriteSuccess(w, http.StatusOK)
+}
@@ -1,11 +1,11 @@
 package authHandler
 
 import (
-	"crypto/rsa"
 	"msn/internal/infra/http/middlewares"
 	"msn/internal/infra/jwt"
 	"msn/internal/infra/logging"
 	"msn/internal/modules/auth"
+	"msn/internal/modules/mfa"
 	"msn/pkg/common/dto"
 	"msn/pkg/common/fault"
 	"msn/pkg/utils/httputils"
@@ -22,14 +22,23 @@ var (
 
 type AuthHandler struct {
 	authService auth.AuthService
-	accessKey   *rsa.PrivateKey
+	mfaService  mfa.Service
+	auth        *middlewares.AuthMiddleware
+	rateLimits  middlewares.RateLimits
 }
 
-func NewHandler(authService auth.AuthService, accessKey *rsa.PrivateKey) *AuthHandler {
+func NewHandler(
+	authService auth.AuthService,
+	mfaService mfa.Service,
+	auth *middlewares.AuthMiddleware,
+	rateLimits middlewares.RateLimits,
+) *AuthHandler {
 	Once.Do(func() {
 		authHandlerInstance = &AuthHandler{
 			authService: authService,
-			accessKey:   accessKey,
+			mfaService:  mfaService,
+			auth:        auth,
+			rateLimits:  rateLimits,
 		}
 	})
 
@@ -37,14 +46,27 @@ func NewHandler(authService auth.AuthService, accessKey *rsa.PrivateKey) *AuthHa
 }
 
 func (h AuthHandler) RegisterRoutes(r *chi.Mux) {
-	m := middlewares.NewWithAuth(h.accessKey)
+	m := h.auth
+	userLimit := h.rateLimits.For(middlewares.RateLimitGroupUser)
+	authLimit := h.rateLimits.For(middlewares.RateLimitGroupAuth)
 	r.Route("/api/v1/auth", func(r chi.Router) {
 		// Private
-		r.With(m.WithAuth).Patch("/logout", h.handleLogout)
+		r.With(m.WithAuth, userLimit).Patch("/logout", h.handleLogout)
+		r.With(m.WithAuth, userLimit).Get("/sessions", h.handleListSessions)
+		r.With(m.WithAuth, userLimit).Delete("/sessions", h.handleRevokeOtherSessions)
+		r.With(m.WithAuth, userLimit).Delete("/sessions/{id}", h.handleRevokeSession)
+		r.With(m.WithAuth, userLimit).Post("/mfa/enroll", h.handleEnrollMFA)
+		r.With(m.WithAuth, userLimit).Post("/mfa/confirm", h.handleConfirmMFA)
+		r.With(m.WithAuth, userLimit).Post("/mfa/disable", h.handleDisableMFA)
+		r.With(m.WithAuth, userLimit).Post("/email/change", h.handleRequestEmailChange)
 
 		// Public
-		r.Post("/login", h.handleLogin)
-		// r.Post("/refresh", h.handleRenewToken)
+		r.With(authLimit).Post("/login", h.handleLogin)
+		r.With(authLimit).Post("/mfa/verify", h.handleVerifyMFA)
+		r.With(authLimit).Post("/refresh", h.handleRenewToken)
+		r.With(authLimit).Post("/password/forgot", h.handleForgotPassword)
+		r.With(authLimit).Post("/password/reset", h.handleResetPassword)
+		r.With(authLimit).Get("/email/confirm", h.handleConfirmEmailChange)
 	})
 }
 
@@ -60,27 +82,91 @@ func (h AuthHandler) handleLogin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	res, err := h.authService.Login(ctx, body.Email, body.Password)
+	res, err := h.authService.Login(ctx, body.Email, body.Password, deviceInfo(r, body.DeviceLabel))
 	if err != nil {
 		logger.ErrorContext(ctx, "login_failed", "error", err)
 		fault.NewHTTPError(w, err)
 		return
 	}
 
-	http.SetCookie(w, &http.Cookie{
-		Name:     "refresh_token",
-		Value:    res.RefreshToken,
-		HttpOnly: true,
-		Secure:   true,
-		SameSite: http.SameSiteNoneMode,
-		Path:     "/",
-		MaxAge:   int(jwt.RefreshTokenDuration.Seconds()),
-	})
+	if res.MFARequired {
+		httputils.WriteJSON(w, http.StatusOK, res)
+		return
+	}
+
+	setRefreshTokenCookie(w, res.RefreshToken)
 
 	logger.InfoContext(ctx, "login_success")
 	httputils.WriteJSON(w, http.StatusOK, res)
 }
 
+func (h AuthHandler) handleVerifyMFA(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var body dto.MFAVerifyRequest
+	if err := httputils.ReadRequestBody(w, r, &body); err != nil {
+		fault.NewHTTPError(w, fault.NewBadRequest(err.Error()))
+		return
+	}
+
+	res, err := h.authService.VerifyMFA(ctx, body, deviceInfo(r, body.DeviceLabel))
+	if err != nil {
+		fault.NewHTTPError(w, err)
+		return
+	}
+
+	setRefreshTokenCookie(w, res.RefreshToken)
+
+	httputils.WriteJSON(w, http.StatusOK, res)
+}
+
+func (h AuthHandler) handleEnrollMFA(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	res, err := h.mfaService.Enroll(ctx)
+	if err != nil {
+		fault.NewHTTPError(w, err)
+		return
+	}
+
+	httputils.WriteJSON(w, http.StatusOK, res)
+}
+
+func (h AuthHandler) handleConfirmMFA(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var body dto.MFACodeRequest
+	if err := httputils.ReadRequestBody(w, r, &body); err != nil {
+		fault.NewHTTPError(w, fault.NewBadRequest(err.Error()))
+		return
+	}
+
+	res, err := h.mfaService.Confirm(ctx, body.Code)
+	if err != nil {
+		fault.NewHTTPError(w, err)
+		return
+	}
+
+	httputils.WriteJSON(w, http.StatusOK, res)
+}
+
+func (h AuthHandler) handleDisableMFA(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var body dto.MFACodeRequest
+	if err := httputils.ReadRequestBody(w, r, &body); err != nil {
+		fault.NewHTTPError(w, fault.NewBadRequest(err.Error()))
+		return
+	}
+
+	if err := h.mfaService.Disable(ctx, body.Code); err != nil {
+		fault.NewHTTPError(w, err)
+		return
+	}
+
+	httputils.WriteSuccess(w, http.StatusOK)
+}
+
 func (h AuthHandler) handleLogout(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	err := h.authService.Logout(ctx)
@@ -102,23 +188,140 @@ func (h AuthHandler) handleLogout(w http.ResponseWriter, r *http.Request) {
 	httputils.WriteSuccess(w, http.StatusOK)
 }
 
-//
-// func (h AuthHandler) handleRenewToken(w http.ResponseWriter, r *http.Request) {
-// 	ctx := r.Context()
-//
-// 	fmt.Println("r.Cookie", r.Cookies())
-//
-// 	cookie, err := r.Cookie("refresh_token")
-// 	if err != nil {
-// 		fault.NewHTTPError(w, fault.NewUnauthorized("refresh token not found"))
-// 		return
-// 	}
-//
-// 	res, err := h.authService.RenewAccessToken(ctx, cookie.Value)
-// 	if err != nil {
-// 		fault.NewHTTPError(w, err)
-// 		return
-// 	}
-//
-// 	httputils.WriteJSON(w, http.StatusOK, res)
-// }
+func (h AuthHandler) handleListSessions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	res, err := h.authService.ListSessions(ctx)
+	if err != nil {
+		fault.NewHTTPError(w, err)
+		return
+	}
+
+	httputils.WriteJSON(w, http.StatusOK, map[string][]*dto.SessionResponse{"sessions": res})
+}
+
+func (h AuthHandler) handleRevokeSession(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	err := h.authService.RevokeSession(ctx, chi.URLParam(r, "id"))
+	if err != nil {
+		fault.NewHTTPError(w, err)
+		return
+	}
+
+	httputils.WriteSuccess(w, http.StatusOK)
+}
+
+func (h AuthHandler) handleRevokeOtherSessions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	res, err := h.authService.RevokeOtherSessions(ctx)
+	if err != nil {
+		fault.NewHTTPError(w, err)
+		return
+	}
+
+	httputils.WriteJSON(w, http.StatusOK, res)
+}
+
+func (h AuthHandler) handleRenewToken(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	cookie, err := r.Cookie("refresh_token")
+	if err != nil {
+		fault.NewHTTPError(w, fault.NewUnauthorized("refresh token not found"))
+		return
+	}
+
+	res, err := h.authService.RenewAccessToken(ctx, cookie.Value, deviceInfo(r, ""))
+	if err != nil {
+		fault.NewHTTPError(w, err)
+		return
+	}
+
+	setRefreshTokenCookie(w, res.RefreshToken)
+
+	httputils.WriteJSON(w, http.StatusOK, res)
+}
+
+func (h AuthHandler) handleForgotPassword(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var body dto.ForgotPasswordRequest
+	if err := httputils.ReadRequestBody(w, r, &body); err != nil {
+		fault.NewHTTPError(w, fault.NewBadRequest(err.Error()))
+		return
+	}
+
+	if err := h.authService.ForgotPassword(ctx, body.Email); err != nil {
+		fault.NewHTTPError(w, err)
+		return
+	}
+
+	httputils.WriteSuccess(w, http.StatusAccepted)
+}
+
+func (h AuthHandler) handleResetPassword(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var body dto.ResetPasswordRequest
+	if err := httputils.ReadRequestBody(w, r, &body); err != nil {
+		fault.NewHTTPError(w, fault.NewBadRequest(err.Error()))
+		return
+	}
+
+	if err := h.authService.ResetPassword(ctx, body); err != nil {
+		fault.NewHTTPError(w, err)
+		return
+	}
+
+	httputils.WriteSuccess(w, http.StatusOK)
+}
+
+func setRefreshTokenCookie(w http.ResponseWriter, refreshToken string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     "refresh_token",
+		Value:    refreshToken,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteNoneMode,
+		Path:     "/",
+		MaxAge:   int(jwt.RefreshTokenDuration.Seconds()),
+	})
+}
+
+func deviceInfo(r *http.Request, label string) dto.DeviceInfo {
+	return dto.DeviceInfo{
+		Label:     label,
+		UserAgent: r.UserAgent(),
+		IPAddress: httputils.ClientIP(r),
+	}
+}
+
+func (h AuthHandler) handleRequestEmailChange(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var body dto.ChangeEmailRequest
+	if err := httputils.ReadRequestBody(w, r, &body); err != nil {
+		fault.NewHTTPError(w, fault.NewBadRequest(err.Error()))
+		return
+	}
+
+	if err := h.authService.RequestEmailChange(ctx, body); err != nil {
+		fault.NewHTTPError(w, err)
+		return
+	}
+
+	httputils.WriteSuccess(w, http.StatusAccepted)
+}
+
+func (h AuthHandler) handleConfirmEmailChange(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if err := h.authService.ConfirmEmailChange(ctx, r.URL.Query().Get("token")); err != nil {
+		fault.NewHTTPError(w, err)
+		return
+	}
+
+	httputils.WriteSuccess(w, http.StatusOK)
+}
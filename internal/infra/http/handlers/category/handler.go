@@ -1,16 +1,26 @@
 package categoryRepository
 
 import (
+	"fmt"
+	"msn/internal/infra/http/middlewares"
 	"msn/internal/modules/category"
+	"msn/internal/modules/role"
 	"msn/pkg/common/dto"
 	"msn/pkg/common/fault"
 	"msn/pkg/utils/httputils"
+	"msn/pkg/utils/locale"
 	"net/http"
+	"strconv"
+	"strings"
 	"sync"
 
 	"github.com/go-chi/chi"
 )
 
+// DefaultCacheControl lets clients and shared caches reuse the public reads
+// for a minute.
+const DefaultCacheControl = "public, max-age=60"
+
 var (
 	categoryHandlerInstance *handler
 	Once                    sync.Once
@@ -18,21 +28,57 @@ var (
 
 type handler struct {
 	categoriesService category.Service
+	locales           *locale.Matcher
+	cacheControl      string
+	auth              *middlewares.AuthMiddleware
+	rateLimits        middlewares.RateLimits
 }
 
-func NewHandler(categoriesService category.Service) *handler {
+func NewHandler(
+	categoriesService category.Service,
+	locales *locale.Matcher,
+	cacheControl string,
+	auth *middlewares.AuthMiddleware,
+	rateLimits middlewares.RateLimits,
+) *handler {
 	Once.Do(func() {
 		categoryHandlerInstance = &handler{
 			categoriesService: categoriesService,
+			locales:           locales,
+			cacheControl:      cacheControl,
+			auth:              auth,
+			rateLimits:        rateLimits,
 		}
 	})
 	return categoryHandlerInstance
 }
 
 func (h handler) RegisterRoutes(r *chi.Mux) {
+	publicLimit := h.rateLimits.For(middlewares.RateLimitGroupPublic)
 	r.Route("/api/v1/categories", func(r chi.Router) {
 		// Public
-		r.Get("/", h.handleGetCategories)
+		r.With(publicLimit).Get("/", h.handleGetCategories)
+		r.With(publicLimit).Get("/tree", h.handleGetTree)
+		r.With(publicLimit).Get("/stats", h.handleGetStats)
+		r.With(publicLimit).Get("/{slug}", h.handleGetCategory)
+	})
+
+	// Admin
+	admin := chi.Chain(
+		h.auth.WithAuth,
+		h.rateLimits.For(middlewares.RateLimitGroupUser),
+		middlewares.RequirePermission(role.PermissionCategoriesWrite),
+	)
+	r.Route("/api/v1/admin/categories", func(r chi.Router) {
+		r.Use(admin...)
+		r.Post("/", h.handleCreateCategory)
+		r.Put("/order", h.handleReorderCategories)
+		r.Patch("/{id}", h.handleUpdateCategory)
+		r.Delete("/{id}", h.handleDeleteCategory)
+		r.Post("/{id}/restore", h.handleRestoreCategory)
+		r.Get("/{id}/translations", h.handleGetTranslations)
+		r.Put("/{id}/translations/{locale}", h.handleSetTranslation)
+		r.Delete("/{id}/translations/{locale}", h.handleDeleteTranslation)
 	})
 }
 
@@ -40,13 +86,242 @@ func (h handler) handleGetCategories(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	includeSubs := r.URL.Query().Get("include") == "subcategories"
 
-	categories, err := h.categoriesService.GetCategories(ctx, includeSubs)
+	categories, err := h.categoriesService.GetCategories(ctx, includeSubs, h.locale(w, r))
+	if err != nil {
+		fault.NewHTTPError(w, err)
+		return
+	}
+
+	httputils.WriteCachedJSON(w, r, http.StatusOK, map[string][]*dto.Category{
+		"categories": categories,
+	}, h.cacheControl)
+}
+
+func (h handler) handleGetTree(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	categories, err := h.categoriesService.GetTree(ctx, h.locale(w, r))
 	if err != nil {
 		fault.NewHTTPError(w, err)
 		return
 	}
 
-	httputils.WriteJSON(w, http.StatusOK, map[string][]*dto.Category{
+	httputils.WriteCachedJSON(w, r, http.StatusOK, map[string][]*dto.Category{
 		"categories": categories,
+	}, h.cacheControl)
+}
+
+func (h handler) handleGetStats(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	days := 30
+	if v := r.URL.Query().Get("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			fault.NewHTTPError(w, fault.NewBadRequest("days must be a number"))
+			return
+		}
+		days = n
+	}
+
+	res, err := h.categoriesService.GetStats(ctx, days, h.locale(w, r))
+	if err != nil {
+		fault.NewHTTPError(w, err)
+		return
+	}
+
+	httputils.WriteCachedJSON(w, r, http.StatusOK, res, h.cacheControl)
+}
+
+func (h handler) handleGetCategory(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	res, err := h.categoriesService.GetBySlug(ctx, chi.URLParam(r, "slug"), h.locale(w, r))
+	if err != nil {
+		fault.NewHTTPError(w, err)
+		return
+	}
+
+	httputils.WriteCachedJSON(w, r, http.StatusOK, res, h.cacheControl)
+}
+
+func (h handler) handleCreateCategory(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var body dto.CreateCategoryRequest
+	if err := httputils.ReadRequestBody(w, r, &body); err != nil {
+		fault.NewHTTPError(w, fault.NewBadRequest(err.Error()))
+		return
+	}
+
+	res, err := h.categoriesService.CreateCategory(ctx, body)
+	if err != nil {
+		fault.NewHTTPError(w, err)
+		return
+	}
+
+	httputils.WriteJSON(w, http.StatusCreated, res)
+}
+
+func (h handler) handleUpdateCategory(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var body dto.UpdateCategoryRequest
+	if err := httputils.ReadRequestBody(w, r, &body); err != nil {
+		fault.NewHTTPError(w, fault.NewBadRequest(err.Error()))
+		return
+	}
+
+	res, err := h.categoriesService.UpdateCategory(ctx, chi.URLParam(r, "id"), body)
+	if err != nil {
+		fault.NewHTTPError(w, err)
+		return
+	}
+
+	httputils.WriteJSON(w, http.StatusOK, res)
+}
+
+func (h handler) handleReorderCategories(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	body, err := readOrder(w, r)
+	if err != nil {
+		fault.NewHTTPError(w, err)
+		return
+	}
+
+	if err := h.categoriesService.ReorderCategories(ctx, body.ParentID, body.IDs); err != nil {
+		fault.NewHTTPError(w, err)
+		return
+	}
+
+	httputils.WriteSuccess(w, http.StatusOK)
+}
+
+func (h handler) handleDeleteCategory(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var replacementID *string
+	if v := r.URL.Query().Get("replacement_id"); v != "" {
+		replacementID = &v
+	}
+
+	res, err := h.categoriesService.DeleteCategory(ctx, chi.URLParam(r, "id"), replacementID)
+	if err != nil {
+		fault.NewHTTPError(w, err)
+		return
+	}
+
+	httputils.WriteJSON(w, http.StatusOK, res)
+}
+
+func (h handler) handleRestoreCategory(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	res, err := h.categoriesService.RestoreCategory(ctx, chi.URLParam(r, "id"))
+	if err != nil {
+		fault.NewHTTPError(w, err)
+		return
+	}
+
+	httputils.WriteJSON(w, http.StatusOK, res)
+}
+
+func (h handler) handleGetTranslations(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	translations, err := h.categoriesService.GetTranslations(ctx, chi.URLParam(r, "id"))
+	if err != nil {
+		fault.NewHTTPError(w, err)
+		return
+	}
+
+	httputils.WriteJSON(w, http.StatusOK, map[string]any{
+		"default_locale": h.locales.Default(),
+		"translations":   translations,
 	})
 }
+
+func (h handler) handleSetTranslation(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	translationLocale, err := h.translationLocale(r)
+	if err != nil {
+		fault.NewHTTPError(w, err)
+		return
+	}
+
+	var body dto.CategoryTranslationRequest
+	if err := httputils.ReadRequestBody(w, r, &body); err != nil {
+		fault.NewHTTPError(w, fault.NewBadRequest(err.Error()))
+		return
+	}
+
+	res, err := h.categoriesService.SetTranslation(ctx, chi.URLParam(r, "id"), translationLocale, body.Name)
+	if err != nil {
+		fault.NewHTTPError(w, err)
+		return
+	}
+
+	httputils.WriteJSON(w, http.StatusOK, res)
+}
+
+func (h handler) handleDeleteTranslation(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	translationLocale, err := h.translationLocale(r)
+	if err != nil {
+		fault.NewHTTPError(w, err)
+		return
+	}
+
+	if err := h.categoriesService.DeleteTranslation(ctx, chi.URLParam(r, "id"), translationLocale); err != nil {
+		fault.NewHTTPError(w, err)
+		return
+	}
+
+	httputils.WriteSuccess(w, http.StatusOK)
+}
+
+// locale picks the locale of a public read and announces it in the response,
+// which varies with Accept-Language.
+func (h handler) locale(w http.ResponseWriter, r *http.Request) string {
+	l := h.locales.FromRequest(r)
+	w.Header().Set("Content-Language", l)
+	w.Header().Add("Vary", "Accept-Language")
+	return l
+}
+
+// translationLocale reads the locale of a translation route, which must be a
+// supported locale other than the default one.
+func (h handler) translationLocale(r *http.Request) (string, error) {
+	l, ok := h.locales.Lookup(chi.URLParam(r, "locale"))
+	if !ok {
+		return "", fault.NewBadRequest("unsupported locale, use one of " + strings.Join(h.locales.Supported(), ", "))
+	}
+	if l == h.locales.Default() {
+		return "", fault.NewBadRequest("names in the default locale are changed by renaming the category")
+	}
+	return l, nil
+}
+
+// readOrder reads a reorder request, whose ids must be unique.
+func readOrder(w http.ResponseWriter, r *http.Request) (*dto.ReorderRequest, error) {
+	var body dto.ReorderRequest
+	if err := httputils.ReadRequestBody(w, r, &body); err != nil {
+		return nil, fault.NewBadRequest(err.Error())
+	}
+	if len(body.IDs) == 0 {
+		return nil, fault.NewBadRequest("ids is required")
+	}
+
+	seen := make(map[string]struct{}, len(body.IDs))
+	for _, id := range body.IDs {
+		if _, ok := seen[id]; ok {
+			return nil, fault.NewBadRequest(fmt.Sprintf("id %s is repeated", id))
+		}
+		seen[id] = struct{}{}
+	}
+
+	return &body, nil
+}
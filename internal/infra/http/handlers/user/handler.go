@@ -2,12 +2,17 @@ package userHandler
 
 import (
 	"fmt"
+	"msn/internal/infra/http/middlewares"
 	"msn/internal/infra/storage"
+	"msn/internal/modules/auth"
+	"msn/internal/modules/dataexport"
 	"msn/internal/modules/user"
 	"msn/pkg/common/dto"
 	"msn/pkg/common/fault"
 	"msn/pkg/utils/httputils"
 	"net/http"
+	"strconv"
+	"strings"
 	"sync"
 
 	"github.com/go-chi/chi"
@@ -20,15 +25,30 @@ var (
 
 type UserHandler struct {
 	userService   user.UserService
+	authService   auth.AuthService
+	exportService dataexport.Service
 	storageClient *storage.StorageClient
+	auth          *middlewares.AuthMiddleware
+	rateLimits    middlewares.RateLimits
 }
 
-func NewHandler(userService user.UserService, storageClient *storage.StorageClient) *UserHandler {
+func NewHandler(
+	userService user.UserService,
+	authService auth.AuthService,
+	exportService dataexport.Service,
+	storageClient *storage.StorageClient,
+	auth *middlewares.AuthMiddleware,
+	rateLimits middlewares.RateLimits,
+) *UserHandler {
 	Once.Do(
 		func() {
 			instance = &UserHandler{
 				userService:   userService,
+				authService:   authService,
+				exportService: exportService,
 				storageClient: storageClient,
+				auth:          auth,
+				rateLimits:    rateLimits,
 			}
 		},
 	)
@@ -36,11 +56,25 @@ func NewHandler(userService user.UserService, storageClient *storage.StorageClie
 }
 
 func (h UserHandler) RegisterRoutes(r *chi.Mux) {
+	m := h.auth
+	userLimit := h.rateLimits.For(middlewares.RateLimitGroupUser)
+	registerLimit := h.rateLimits.For(middlewares.RateLimitGroupRegister)
+	publicLimit := h.rateLimits.For(middlewares.RateLimitGroupPublic)
+	authLimit := h.rateLimits.For(middlewares.RateLimitGroupAuth)
 	r.Route(
 		"/api/v1/users", func(r chi.Router) {
+			// Private
+			r.With(m.WithAuth, userLimit).Get("/me", h.handleGetMe)
+			r.With(m.WithAuth, userLimit).Patch("/me", h.handleUpdateMe)
+			r.With(m.WithAuth, userLimit).Delete("/me", h.handleDeleteMe)
+			r.With(m.WithAuth, userLimit).Post("/me/password", h.handleChangePassword)
+			r.With(m.WithAuth, userLimit).Post("/me/export", h.handleRequestExport)
+
 			// Public
-			r.Post("/register", h.handleRegister)
-			r.Get("/professionals", h.handleGetProfessionals)
+			r.With(registerLimit).Post("/register", h.handleRegister)
+			r.With(publicLimit).Get("/professionals", h.handleGetProfessionals)
+			r.With(authLimit).Get("/verify", h.handleVerifyEmail)
+			r.With(authLimit).Post("/verify/resend", h.handleResendVerification)
 		},
 	)
 }
@@ -115,3 +149,160 @@ func (h UserHandler) handleGetProfessionals(w http.ResponseWriter, r *http.Reque
 
 	httputils.WriteJSON(w, http.StatusOK, map[string][]*dto.ProfessionalUserResponse{"professionals": res})
 }
+
+func (h UserHandler) handleGetMe(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	res, err := h.userService.GetMe(ctx)
+	if err != nil {
+		fault.NewHTTPError(w, err)
+		return
+	}
+
+	httputils.WriteJSON(w, http.StatusOK, res)
+}
+
+func (h UserHandler) handleUpdateMe(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	body, err := readProfileUpdate(w, r)
+	if err != nil {
+		fault.NewHTTPError(w, err)
+		return
+	}
+
+	res, err := h.userService.UpdateMe(ctx, *body)
+	if err != nil {
+		fault.NewHTTPError(w, err)
+		return
+	}
+
+	httputils.WriteJSON(w, http.StatusOK, res)
+}
+
+// readProfileUpdate reads a profile update sent as JSON or, to replace the
+// picture, as a multipart form. Fields left out of either are not changed.
+func readProfileUpdate(w http.ResponseWriter, r *http.Request) (*dto.UpdateProfile, error) {
+	var body dto.UpdateProfile
+
+	if !strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		if err := httputils.ReadRequestBody(w, r, &body); err != nil {
+			return nil, fault.NewBadRequest(err.Error())
+		}
+		return &body, nil
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, 6<<20)
+	if err := r.ParseMultipartForm(5 << 20); err != nil {
+		return nil, fault.NewBadRequest(fmt.Sprintf("invalid form data: %s", err))
+	}
+
+	form := r.MultipartForm.Value
+	if v, ok := form["name"]; ok {
+		body.Name = &v[0]
+	}
+	if v, ok := form["subcategory_id"]; ok {
+		body.SubcategoryID = &v[0]
+	}
+	if v, ok := form["version"]; ok {
+		version, err := strconv.Atoi(v[0])
+		if err != nil {
+			return nil, fault.NewBadRequest("version must be a number")
+		}
+		body.Version = &version
+	}
+
+	if files := r.MultipartForm.File["picture"]; len(files) > 0 {
+		if files[0].Size > 5<<20 {
+			return nil, fault.NewBadRequest("picture must be at most 5mb")
+		}
+		body.FileHeader = files[0]
+	}
+
+	return &body, nil
+}
+
+func (h UserHandler) handleVerifyEmail(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	err := h.userService.VerifyEmail(ctx, r.URL.Query().Get("token"))
+	if err != nil {
+		fault.NewHTTPError(w, err)
+		return
+	}
+
+	httputils.WriteSuccess(w, http.StatusOK)
+}
+
+func (h UserHandler) handleResendVerification(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var body dto.ResendVerificationRequest
+	if err := httputils.ReadRequestBody(w, r, &body); err != nil {
+		fault.NewHTTPError(w, fault.NewBadRequest(err.Error()))
+		return
+	}
+
+	if err := h.userService.ResendVerification(ctx, body.Email); err != nil {
+		fault.NewHTTPError(w, err)
+		return
+	}
+
+	httputils.WriteSuccess(w, http.StatusAccepted)
+}
+
+func (h UserHandler) handleChangePassword(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var body dto.ChangePasswordRequest
+	if err := httputils.ReadRequestBody(w, r, &body); err != nil {
+		fault.NewHTTPError(w, fault.NewBadRequest(err.Error()))
+		return
+	}
+
+	if err := h.authService.ChangePassword(ctx, body); err != nil {
+		fault.NewHTTPError(w, err)
+		return
+	}
+
+	httputils.WriteSuccess(w, http.StatusOK)
+}
+
+func (h UserHandler) handleDeleteMe(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var body dto.DeleteAccountRequest
+	if err := httputils.ReadRequestBody(w, r, &body); err != nil {
+		fault.NewHTTPError(w, fault.NewBadRequest(err.Error()))
+		return
+	}
+
+	if err := h.authService.DeleteAccount(ctx, body); err != nil {
+		fault.NewHTTPError(w, err)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     "refresh_token",
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteStrictMode,
+	})
+
+	httputils.WriteSuccess(w, http.StatusOK)
+}
+
+func (h UserHandler) handleRequestExport(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	res, err := h.exportService.Request(ctx)
+	if err != nil {
+		fault.NewHTTPError(w, err)
+		return
+	}
+
+	httputils.WriteJSON(w, http.StatusAccepted, res)
+}
@@ -0,0 +1,44 @@
+package wellknownHandler
+
+import (
+	"msn/pkg/utils/httputils"
+	"msn/pkg/utils/jwks"
+	"net/http"
+	"sync"
+
+	"github.com/go-chi/chi"
+)
+
+var (
+	instance *WellKnownHandler
+	Once     sync.Once
+)
+
+type KeySetProvider interface {
+	JWKS() jwks.Set
+}
+
+type WellKnownHandler struct {
+	keys KeySetProvider
+}
+
+func NewHandler(keys KeySetProvider) *WellKnownHandler {
+	Once.Do(func() {
+		instance = &WellKnownHandler{
+			keys: keys,
+		}
+	})
+	return instance
+}
+
+func (h WellKnownHandler) RegisterRoutes(r *chi.Mux) {
+	r.Route("/.well-known", func(r chi.Router) {
+		// Public
+		r.Get("/jwks.json", h.handleJWKS)
+	})
+}
+
+func (h WellKnownHandler) handleJWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	httputils.WriteJSON(w, http.StatusOK, h.keys.JWKS())
+}
@@ -2,8 +2,8 @@ package middlewares
 
 import (
 	"context"
-	"crypto/rsa"
 	"msn/internal/infra/jwt"
+	"msn/internal/infra/logging"
 	"msn/pkg/common/fault"
 	"net/http"
 	"strings"
@@ -11,17 +11,25 @@ import (
 
 type AuthKey struct{}
 
-type middleware struct {
-	accessKey *rsa.PrivateKey
+// RevocationChecker reports whether an access token was revoked before it
+// expired.
+type RevocationChecker interface {
+	IsRevoked(ctx context.Context, sessionID string) (bool, error)
 }
 
-func NewWithAuth(accessKey *rsa.PrivateKey) *middleware {
-	return &middleware{
-		accessKey: accessKey,
+type AuthMiddleware struct {
+	accessKey   *jwt.Keyring
+	revocations RevocationChecker
+}
+
+func NewWithAuth(accessKey *jwt.Keyring, revocations RevocationChecker) *AuthMiddleware {
+	return &AuthMiddleware{
+		accessKey:   accessKey,
+		revocations: revocations,
 	}
 }
 
-func (m *middleware) WithAuth(next http.Handler) http.Handler {
+func (m *AuthMiddleware) WithAuth(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		accessToken := r.Header.Get("Authorization")
 
@@ -40,8 +48,30 @@ func (m *middleware) WithAuth(next http.Handler) http.Handler {
 			return
 		}
 
+		if !claims.IsAccessToken() {
+			fault.NewHTTPError(w, fault.NewUnauthorized("invalid access token"))
+			return
+		}
+
+		revoked, err := m.revocations.IsRevoked(r.Context(), claims.SessionID)
+		if err != nil {
+			logging.FromContext(r.Context()).ErrorContext(r.Context(), "revocation_check_failed", "error", err)
+			fault.NewHTTPError(w, fault.NewInternalServerError("failed to validate access token"))
+			return
+		}
+		if revoked {
+			fault.NewHTTPError(w, fault.NewUnauthorized("token has been revoked"))
+			return
+		}
+
 		ctx := context.WithValue(r.Context(), AuthKey{}, claims)
 
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
+
+// ClaimsFromContext returns the claims WithAuth stored in the context.
+func ClaimsFromContext(ctx context.Context) (*jwt.Claims, bool) {
+	claims, ok := ctx.Value(AuthKey{}).(*jwt.Claims)
+	return claims, ok
+}
@@ -0,0 +1,42 @@
+package middlewares
+
+import (
+	"fmt"
+	"msn/internal/infra/logging"
+	"msn/pkg/common/fault"
+	"net/http"
+)
+
+// RequirePermission only lets through requests whose access token grants all
+// the given permissions. It reads the claims set by WithAuth, so it must be
+// mounted after it.
+func RequirePermission(permissions ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+
+			claims, ok := ClaimsFromContext(ctx)
+			if !ok {
+				fault.NewHTTPError(w, fault.NewUnauthorized("access token not provided"))
+				return
+			}
+
+			for _, permission := range permissions {
+				if claims.HasScope(permission) {
+					continue
+				}
+
+				logging.FromContext(ctx).WarnContext(ctx, "security_event",
+					"event", "permission_denied",
+					"user_id", claims.Subject,
+					"permission", permission,
+					"path", r.URL.Path,
+				)
+				fault.NewHTTPError(w, fault.NewForbidden(fmt.Sprintf("missing permission %s", permission)))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package middlewares
+
+import (
+	"msn/pkg/utils/httputils"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// RealIP replaces r.RemoteAddr with the client address forwarded by a
+// trusted proxy, taken from X-Forwarded-For or X-Real-IP. Requests from any
+// other peer keep their own address, so clients cannot pick the IP that
+// lockouts and rate limits count them under. It must run before anything
+// that reads the client IP.
+func RealIP(trusted []netip.Prefix) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if len(trusted) == 0 {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if ip, ok := forwardedIP(r, trusted); ok {
+				r.RemoteAddr = net.JoinHostPort(ip.String(), "0")
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func forwardedIP(r *http.Request, trusted []netip.Prefix) (netip.Addr, bool) {
+	peer, err := netip.ParseAddr(httputils.ClientIP(r))
+	if err != nil || !isTrusted(peer, trusted) {
+		return netip.Addr{}, false
+	}
+
+	// Each proxy appends the address it got the request from, so the client
+	// is the right-most entry not added by a trusted proxy.
+	if header := r.Header.Values("X-Forwarded-For"); len(header) > 0 {
+		hops := strings.Split(strings.Join(header, ","), ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			ip, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+			if err != nil {
+				return netip.Addr{}, false
+			}
+			if !isTrusted(ip, trusted) || i == 0 {
+				return ip.Unmap(), true
+			}
+		}
+	}
+
+	if ip, err := netip.ParseAddr(strings.TrimSpace(r.Header.Get("X-Real-IP"))); err == nil {
+		return ip.Unmap(), true
+	}
+
+	return netip.Addr{}, false
+}
+
+func isTrusted(ip netip.Addr, trusted []netip.Prefix) bool {
+	ip = ip.Unmap()
+	for _, prefix := range trusted {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -1,36 +1,84 @@
 package jwt
 
 import (
-	"crypto/rsa"
 	"fmt"
 	"msn/pkg/common/dto"
+	"msn/pkg/utils/jwks"
 	"msn/pkg/utils/uid"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
+const (
+	TokenUseAccess     = "access"
+	TokenUseRefresh    = "refresh"
+	TokenUseMFAPending = "mfa_pending"
+)
+
+// ClaimsVersion is the schema written in the ver claim. Version 1 tokens
+// have no ver and embed the whole user instead of sub and role.
+const ClaimsVersion = 2
+
 type Claims struct {
-	User *dto.EnrichedUserResponse `json:"user"`
+	Version   int      `json:"ver,omitempty"`
+	Role      string   `json:"role,omitempty"`
+	Scopes    []string `json:"scopes,omitempty"`
+	SessionID string   `json:"sid,omitempty"`
+	TokenUse  string   `json:"token_use,omitempty"`
+	// LegacyUser is only read from version 1 tokens.
+	//
+	// Deprecated: drop once version 1 tokens are no longer accepted.
+	LegacyUser *dto.EnrichedUserResponse `json:"user,omitempty"`
 	jwt.RegisteredClaims
 }
 
-func NewClaims(user *dto.EnrichedUserResponse, duration time.Duration) (*Claims, error) {
+// Subject is who a token is issued to.
+type Subject struct {
+	ID     string
+	Role   string
+	Scopes []string
+}
+
+func SubjectFromUser(user *dto.EnrichedUserResponse) Subject {
+	subject := Subject{ID: user.ID}
+	if user.Role != nil {
+		subject.Role = user.Role.Name
+		subject.Scopes = user.Role.Permissions
+	}
+	return subject
+}
+
+func NewClaims(
+	subject Subject,
+	sessionID string,
+	tokenUse string,
+	duration time.Duration,
+) (*Claims, error) {
 	jti := uid.New("jti")
 
 	return &Claims{
-		User: user,
+		Version:   ClaimsVersion,
+		Role:      subject.Role,
+		Scopes:    subject.Scopes,
+		SessionID: sessionID,
+		TokenUse:  tokenUse,
 		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   subject.ID,
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(duration)),
-			Issuer:    "user-service",
+			Issuer:    jwks.DefaultIssuer,
 			ID:        jti,
 		},
 	}, nil
 }
 
-func Verify(secretKey *rsa.PrivateKey, v string) (*Claims, error) {
+// Verify checks the token against the keyring key named by its kid, and
+// only with the algorithm that key signs with. Tokens issued before kid was
+// added are checked against the active key.
+func Verify(keyring *Keyring, v string) (*Claims, error) {
 	if strings.TrimSpace(v) == "" {
 		return nil, fmt.Errorf("invalid token")
 	}
@@ -38,10 +86,17 @@ func Verify(secretKey *rsa.PrivateKey, v string) (*Claims, error) {
 	v = strings.TrimPrefix(v, "Bearer ")
 
 	keyFunc := func(token *jwt.Token) (any, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+		key := keyring.Active()
+		if kid, ok := token.Header["kid"].(string); ok {
+			key, ok = keyring.Lookup(kid)
+			if !ok {
+				return nil, fmt.Errorf("unknown key id %q", kid)
+			}
+		}
+		if token.Method.Alg() != key.Method.Alg() {
 			return nil, fmt.Errorf("invalid token signing method")
 		}
-		return &secretKey.PublicKey, nil
+		return key.PublicKey(), nil
 	}
 
 	token, err := jwt.ParseWithClaims(v, &Claims{}, keyFunc)
@@ -54,5 +109,40 @@ func Verify(secretKey *rsa.PrivateKey, v string) (*Claims, error) {
 		return nil, fmt.Errorf("invalid token claims")
 	}
 
+	if err := claims.upgrade(); err != nil {
+		return nil, err
+	}
+
 	return claims, nil
 }
+
+// upgrade fills sub and role from the user embedded in version 1 tokens, so
+// callers only deal with the current schema.
+func (c *Claims) upgrade() error {
+	if c.Version >= ClaimsVersion {
+		return nil
+	}
+
+	if c.LegacyUser == nil || c.LegacyUser.ID == "" {
+		return fmt.Errorf("invalid token claims")
+	}
+
+	subject := SubjectFromUser(c.LegacyUser)
+	c.Version = 1
+	c.Subject = subject.ID
+	c.Role = subject.Role
+	c.LegacyUser = nil
+
+	return nil
+}
+
+// HasScope reports whether the token grants the given permission.
+func (c *Claims) HasScope(scope string) bool {
+	return slices.Contains(c.Scopes, scope)
+}
+
+// IsAccessToken reports whether the claims may authenticate API requests.
+// Tokens issued before token_use existed are all access tokens.
+func (c *Claims) IsAccessToken() bool {
+	return c.TokenUse == "" || c.TokenUse == TokenUseAccess
+}
@@ -0,0 +1,99 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestVerifyKeyAlgorithm(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyring, err := NewKeyring(ecKey, edKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKid := keyring.Active().ID
+	edKid := ""
+	for _, key := range keyring.Keys() {
+		if key.ID != ecKid {
+			edKid = key.ID
+		}
+	}
+
+	tests := []struct {
+		name   string
+		method jwt.SigningMethod
+		signer crypto.Signer
+		kid    string
+		ok     bool
+	}{
+		{"active key", jwt.SigningMethodES256, ecKey, ecKid, true},
+		{"verify-only key", jwt.SigningMethodEdDSA, edKey, edKid, true},
+		{"RS256 token naming an ES256 kid", jwt.SigningMethodRS256, rsaKey, ecKid, false},
+		{"HS256 token naming an ES256 kid", jwt.SigningMethodHS256, nil, ecKid, false},
+		{"EdDSA token naming an ES256 kid", jwt.SigningMethodEdDSA, edKey, ecKid, false},
+		{"unknown kid", jwt.SigningMethodES256, ecKey, "unknown", false},
+		{"kid-less token of the active key", jwt.SigningMethodES256, ecKey, "", true},
+		{"kid-less token of a verify-only key", jwt.SigningMethodEdDSA, edKey, "", false},
+		{"kid-less RS256 token", jwt.SigningMethodRS256, rsaKey, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := signTestToken(t, tt.method, tt.signer, tt.kid)
+
+			_, err := Verify(keyring, token)
+			if tt.ok && err != nil {
+				t.Fatalf("Verify rejected the token: %v", err)
+			}
+			if !tt.ok && err == nil {
+				t.Fatal("Verify accepted the token")
+			}
+		})
+	}
+}
+
+func signTestToken(t *testing.T, method jwt.SigningMethod, signer crypto.Signer, kid string) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(method, jwt.MapClaims{
+		"ver":       ClaimsVersion,
+		"sub":       "user_1",
+		"token_use": TokenUseAccess,
+		"exp":       time.Now().Add(time.Minute).Unix(),
+	})
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+
+	// HS256 stands for a token forged with the public key as HMAC secret.
+	var key any = signer
+	if method == jwt.SigningMethodHS256 {
+		key = []byte("public key bytes")
+	}
+
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
@@ -0,0 +1,126 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"fmt"
+	"msn/pkg/utils/jwks"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Key is a signing key identified by the kid its tokens carry. Its signing
+// method follows from the key type: RS256 for RSA, ES256 for ECDSA P-256 and
+// EdDSA for Ed25519.
+type Key struct {
+	ID     string
+	Signer crypto.Signer
+	Method jwt.SigningMethod
+}
+
+func NewKey(signer crypto.Signer) (*Key, error) {
+	var method jwt.SigningMethod
+	switch signer.(type) {
+	case *rsa.PrivateKey:
+		method = jwt.SigningMethodRS256
+	case *ecdsa.PrivateKey:
+		method = jwt.SigningMethodES256
+	case ed25519.PrivateKey:
+		method = jwt.SigningMethodEdDSA
+	default:
+		return nil, fmt.Errorf("unsupported signing key type %T", signer)
+	}
+
+	kid, err := KeyID(signer.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	return &Key{
+		ID:     kid,
+		Signer: signer,
+		Method: method,
+	}, nil
+}
+
+// PublicKey returns the key tokens are verified with.
+func (k *Key) PublicKey() crypto.PublicKey {
+	return k.Signer.Public()
+}
+
+// KeyID returns the kid tokens signed by the key carry.
+func KeyID(publicKey crypto.PublicKey) (string, error) {
+	key, err := jwks.NewKey(publicKey)
+	if err != nil {
+		return "", err
+	}
+	return key.Kid, nil
+}
+
+// Keyring holds the key new tokens are signed with and the keys that are
+// still accepted for verification. Rotating keeps the previous key in the
+// ring until every token it signed has expired.
+type Keyring struct {
+	active *Key
+	keys   map[string]*Key
+	order  []string
+}
+
+func NewKeyring(active crypto.Signer, verifyOnly ...crypto.Signer) (*Keyring, error) {
+	if active == nil {
+		return nil, fmt.Errorf("keyring requires an active key")
+	}
+
+	k := &Keyring{keys: make(map[string]*Key)}
+
+	var err error
+	k.active, err = k.add(active)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range verifyOnly {
+		if key == nil {
+			continue
+		}
+		if _, err := k.add(key); err != nil {
+			return nil, err
+		}
+	}
+
+	return k, nil
+}
+
+func (k *Keyring) add(signer crypto.Signer) (*Key, error) {
+	key, err := NewKey(signer)
+	if err != nil {
+		return nil, err
+	}
+	if existing, ok := k.keys[key.ID]; ok {
+		return existing, nil
+	}
+	k.keys[key.ID] = key
+	k.order = append(k.order, key.ID)
+	return key, nil
+}
+
+// Active returns the key new tokens are signed with.
+func (k *Keyring) Active() *Key {
+	return k.active
+}
+
+// Lookup returns the key with the given kid.
+func (k *Keyring) Lookup(kid string) (*Key, bool) {
+	key, ok := k.keys[kid]
+	return key, ok
+}
+
+// Keys returns every key in the ring, the active one first.
+func (k *Keyring) Keys() []*Key {
+	keys := make([]*Key, 0, len(k.order))
+	for _, id := range k.order {
+		keys = append(keys, k.keys[id])
+	}
+	return keys
+}
@@ -0,0 +1,190 @@
+package jwt
+
+import (
+	stdcrypto "crypto"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"msn/pkg/utils/crypto"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// A key directory holds one PKCS#8 PEM file per key, named <kid>.pem, and a
+// keys.json manifest recording which key is active and when the others
+// stopped signing.
+const manifestFile = "keys.json"
+
+type manifest struct {
+	Active string          `json:"active"`
+	Keys   []manifestEntry `json:"keys"`
+}
+
+type manifestEntry struct {
+	ID            string     `json:"kid"`
+	CreatedAt     time.Time  `json:"created_at"`
+	DeactivatedAt *time.Time `json:"deactivated_at,omitempty"`
+}
+
+// LoadKeyringDir builds a keyring from a key directory.
+func LoadKeyringDir(dir string) (*Keyring, error) {
+	m, err := readManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+	if m.Active == "" {
+		return nil, fmt.Errorf("no active key in %s", dir)
+	}
+
+	active, err := readKey(dir, m.Active)
+	if err != nil {
+		return nil, err
+	}
+
+	var verifyOnly []stdcrypto.Signer
+	for _, entry := range m.Keys {
+		if entry.ID == m.Active {
+			continue
+		}
+		key, err := readKey(dir, entry.ID)
+		if err != nil {
+			return nil, err
+		}
+		verifyOnly = append(verifyOnly, key)
+	}
+
+	return NewKeyring(active, verifyOnly...)
+}
+
+// GenerateKeyInDir creates a key for the algorithm in the directory and
+// makes it the active one. The previous active key is kept for verification.
+func GenerateKeyInDir(dir, algorithm string) (string, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", fmt.Errorf("failed to create key directory: %w", err)
+	}
+
+	m, err := readManifest(dir)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+
+	data, err := crypto.GeneratePrivateKeyPEM(algorithm)
+	if err != nil {
+		return "", err
+	}
+	privateKey, err := crypto.ParsePrivateKeyPEM(data)
+	if err != nil {
+		return "", err
+	}
+	kid, err := KeyID(privateKey.Public())
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.WriteFile(keyPath(dir, kid), data, 0o600); err != nil {
+		return "", fmt.Errorf("failed to write key: %w", err)
+	}
+
+	now := time.Now().UTC()
+	for i := range m.Keys {
+		if m.Keys[i].ID == m.Active && m.Keys[i].DeactivatedAt == nil {
+			m.Keys[i].DeactivatedAt = &now
+		}
+	}
+	m.Keys = append(m.Keys, manifestEntry{ID: kid, CreatedAt: now})
+	m.Active = kid
+
+	return kid, writeManifest(dir, m)
+}
+
+// RetireKeysInDir removes the keys that stopped signing more than grace ago
+// and returns their kids.
+func RetireKeysInDir(dir string, grace time.Duration) ([]string, error) {
+	m, err := readManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		kept    []manifestEntry
+		retired []string
+	)
+	cutoff := time.Now().Add(-grace)
+	for _, entry := range m.Keys {
+		if entry.ID == m.Active || entry.DeactivatedAt == nil || entry.DeactivatedAt.After(cutoff) {
+			kept = append(kept, entry)
+			continue
+		}
+		retired = append(retired, entry.ID)
+	}
+
+	if len(retired) == 0 {
+		return nil, nil
+	}
+
+	m.Keys = kept
+	if err := writeManifest(dir, m); err != nil {
+		return nil, err
+	}
+
+	for _, kid := range retired {
+		if err := os.Remove(keyPath(dir, kid)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return retired, fmt.Errorf("failed to remove key %s: %w", kid, err)
+		}
+	}
+
+	return retired, nil
+}
+
+func readManifest(dir string) (manifest, error) {
+	var m manifest
+
+	data, err := os.ReadFile(filepath.Join(dir, manifestFile))
+	if err != nil {
+		return m, fmt.Errorf("failed to read key manifest: %w", err)
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return m, fmt.Errorf("failed to decode key manifest: %w", err)
+	}
+
+	return m, nil
+}
+
+// writeManifest replaces the manifest atomically so a running instance never
+// reads a partial file.
+func writeManifest(dir string, m manifest) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode key manifest: %w", err)
+	}
+
+	tmp := filepath.Join(dir, manifestFile+".tmp")
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write key manifest: %w", err)
+	}
+
+	return os.Rename(tmp, filepath.Join(dir, manifestFile))
+}
+
+func readKey(dir, kid string) (stdcrypto.Signer, error) {
+	data, err := os.ReadFile(keyPath(dir, kid))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key %s: %w", kid, err)
+	}
+
+	key, err := crypto.ParsePrivateKeyPEM(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse key %s: %w", kid, err)
+	}
+
+	if id, err := KeyID(key.Public()); err != nil || id != kid {
+		return nil, fmt.Errorf("key file %s does not match its kid", kid)
+	}
+
+	return key, nil
+}
+
+func keyPath(dir, kid string) string {
+	return filepath.Join(dir, kid+".pem")
+}
@@ -1,36 +1,84 @@
 package jwt
 
 import (
-	"crypto/rsa"
+	"fmt"
 	"msn/pkg/common/dto"
+	"msn/pkg/utils/jwks"
 	"time"
 )
 
 const (
-	AccessTokenDuration  = 2 * time.Minute
-	RefreshTokenDuration = 720 * time.Hour
+	AccessTokenDuration     = 2 * time.Minute
+	RefreshTokenDuration    = 720 * time.Hour
+	MFAPendingTokenDuration = 5 * time.Minute
 )
 
 type JWTProvider struct {
-	accessKey  *rsa.PrivateKey
-	refreshKey *rsa.PrivateKey
+	accessKeys  *Keyring
+	refreshKeys *Keyring
+	mfaKeys     *Keyring
 }
 
-func NewProvider(accessKey, refreshKey *rsa.PrivateKey) *JWTProvider {
+// NewProvider builds a provider signing each kind of token with its own
+// keyring. Only the access keys are published, so the other tokens cannot be
+// passed off as access tokens to services verifying against the JWKS.
+func NewProvider(accessKeys, refreshKeys, mfaKeys *Keyring) *JWTProvider {
 	return &JWTProvider{
-		accessKey:  accessKey,
-		refreshKey: refreshKey,
+		accessKeys:  accessKeys,
+		refreshKeys: refreshKeys,
+		mfaKeys:     mfaKeys,
 	}
 }
 
-func (j *JWTProvider) GenerateAccessToken(user *dto.EnrichedUserResponse) (string, *Claims, error) {
-	return GenerateToken(j.accessKey, user, AccessTokenDuration)
+// GenerateAccessToken issues an access token bound to the given session, so
+// the session can be looked up again from the token alone.
+func (j *JWTProvider) GenerateAccessToken(user *dto.EnrichedUserResponse, sessionID string) (string, *Claims, error) {
+	return GenerateToken(j.accessKeys.Active(), SubjectFromUser(user), sessionID, TokenUseAccess, AccessTokenDuration)
 }
 
+// GenerateRefreshToken issues a refresh token. The session is found through
+// the token JTI, so no session ID is embedded.
 func (j *JWTProvider) GenerateRefreshToken(user *dto.EnrichedUserResponse) (string, *Claims, error) {
-	return GenerateToken(j.refreshKey, user, RefreshTokenDuration)
+	return GenerateToken(j.refreshKeys.Active(), SubjectFromUser(user), "", TokenUseRefresh, RefreshTokenDuration)
+}
+
+// GenerateMFAPendingToken issues a short-lived token proving the password
+// step of a login succeeded. It cannot be used as an access token.
+func (j *JWTProvider) GenerateMFAPendingToken(user *dto.EnrichedUserResponse) (string, *Claims, error) {
+	return GenerateToken(j.mfaKeys.Active(), SubjectFromUser(user), "", TokenUseMFAPending, MFAPendingTokenDuration)
+}
+
+func (j *JWTProvider) VerifyMFAPendingToken(tokenStr string) (*Claims, error) {
+	claims, err := Verify(j.mfaKeys, tokenStr)
+	if err != nil {
+		return nil, err
+	}
+	if claims.TokenUse != TokenUseMFAPending {
+		return nil, fmt.Errorf("token is not an mfa pending token")
+	}
+	return claims, nil
 }
 
 func (j *JWTProvider) VerifyRefreshToken(tokenStr string) (*Claims, error) {
-	return Verify(j.refreshKey, tokenStr)
+	claims, err := Verify(j.refreshKeys, tokenStr)
+	if err != nil {
+		return nil, err
+	}
+	if claims.TokenUse != "" && claims.TokenUse != TokenUseRefresh {
+		return nil, fmt.Errorf("token is not a refresh token")
+	}
+	return claims, nil
+}
+
+// JWKS returns the public keys other services need to verify access tokens,
+// including the ones kept for rotation. Refresh and mfa pending tokens are
+// only ever checked here, so their keys are not published.
+func (j *JWTProvider) JWKS() jwks.Set {
+	set := jwks.Set{Keys: []jwks.Key{}}
+	for _, key := range j.accessKeys.Keys() {
+		// Keys in the ring were already accepted by jwks.NewKey.
+		jwk, _ := jwks.NewKey(key.PublicKey())
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
 }
@@ -1,22 +1,27 @@
 package jwt
 
 import (
-	"crypto/rsa"
 	"fmt"
-	"msn/pkg/common/dto"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
-func GenerateToken(secretKey *rsa.PrivateKey, user *dto.EnrichedUserResponse, duration time.Duration) (string, *Claims, error) {
-	claims, err := NewClaims(user, duration)
+func GenerateToken(
+	key *Key,
+	subject Subject,
+	sessionID string,
+	tokenUse string,
+	duration time.Duration,
+) (string, *Claims, error) {
+	claims, err := NewClaims(subject, sessionID, tokenUse, duration)
 	if err != nil {
 		return "", nil, fmt.Errorf("failed to create claims: %w", err)
 	}
 
-	jwtToken := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
-	token, err := jwtToken.SignedString(secretKey)
+	jwtToken := jwt.NewWithClaims(key.Method, claims)
+	jwtToken.Header["kid"] = key.ID
+	token, err := jwtToken.SignedString(key.Signer)
 	if err != nil {
 		return "", claims, fmt.Errorf("failed to sign token: %w", err)
 	}
@@ -0,0 +1,60 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"msn/pkg/common/fault"
+	"net/http"
+)
+
+const (
+	DriverSMTP   = "smtp"
+	DriverOutbox = "outbox"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+type Config struct {
+	Driver       string
+	From         string
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+	OutboxDir    string
+}
+
+// New builds the Mailer selected by c.Driver. The outbox driver is used when
+// no driver is configured, so development never sends real emails.
+func New(c Config) (Mailer, error) {
+	if c.From == "" {
+		return nil, fmt.Errorf("mailer sender address is required")
+	}
+
+	switch c.Driver {
+	case DriverSMTP:
+		return NewSMTPMailer(c.SMTPHost, c.SMTPPort, c.SMTPUsername, c.SMTPPassword, c.From)
+	case DriverOutbox, "":
+		return NewOutboxMailer(c.OutboxDir, c.From)
+	default:
+		return nil, fmt.Errorf("unknown mailer driver: %s", c.Driver)
+	}
+}
+
+func newMailerError(msg string, err error) *fault.Fault {
+	return fault.New(
+		msg,
+		fault.WithTag(fault.MAILER_ERROR),
+		fault.WithHTTPCode(http.StatusInternalServerError),
+		fault.WithError(err),
+	)
+}
@@ -0,0 +1,70 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"msn/internal/infra/logging"
+	"msn/pkg/utils/uid"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+type outboxMailer struct {
+	dir  string
+	from string
+}
+
+// NewOutboxMailer returns a Mailer that writes every message as an .eml file
+// inside dir instead of delivering it. Useful for development and tests.
+func NewOutboxMailer(dir, from string) (Mailer, error) {
+	if dir == "" {
+		dir = "outbox"
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create outbox directory: %w", err)
+	}
+
+	return &outboxMailer{dir: dir, from: from}, nil
+}
+
+func (m *outboxMailer) Send(ctx context.Context, msg Message) error {
+	raw, err := buildMIME(m.from, msg)
+	if err != nil {
+		return newMailerError("failed to build email", err)
+	}
+
+	name := fmt.Sprintf(
+		"%s_%s_%s.eml",
+		time.Now().Format("20060102T150405"),
+		sanitizeFileName(msg.To),
+		uid.New(""),
+	)
+	path := filepath.Join(m.dir, name)
+
+	if err := os.WriteFile(path, raw, 0o644); err != nil {
+		return newMailerError("failed to write email to outbox", err)
+	}
+
+	logging.FromContext(ctx).DebugContext(
+		ctx, "mail_written_to_outbox",
+		"to", msg.To,
+		"subject", msg.Subject,
+		"path", path,
+	)
+
+	return nil
+}
+
+func sanitizeFileName(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-':
+			return r
+		default:
+			return '_'
+		}
+	}, s)
+}
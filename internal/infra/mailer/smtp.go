@@ -0,0 +1,96 @@
+package mailer
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"time"
+)
+
+const smtpDialTimeout = 10 * time.Second
+
+type smtpMailer struct {
+	addr     string
+	host     string
+	username string
+	password string
+	from     string
+}
+
+func NewSMTPMailer(host, port, username, password, from string) (Mailer, error) {
+	if host == "" || port == "" {
+		return nil, fmt.Errorf("smtp host and port are required")
+	}
+
+	return &smtpMailer{
+		addr:     net.JoinHostPort(host, port),
+		host:     host,
+		username: username,
+		password: password,
+		from:     from,
+	}, nil
+}
+
+func (m *smtpMailer) Send(ctx context.Context, msg Message) error {
+	raw, err := buildMIME(m.from, msg)
+	if err != nil {
+		return newMailerError("failed to build email", err)
+	}
+
+	sender, err := mail.ParseAddress(m.from)
+	if err != nil {
+		return newMailerError("invalid sender address", err)
+	}
+
+	dialer := net.Dialer{Timeout: smtpDialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", m.addr)
+	if err != nil {
+		return newMailerError("failed to connect to smtp server", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		conn.Close()
+		return newMailerError("failed to start smtp session", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: m.host}); err != nil {
+			return newMailerError("failed to start tls", err)
+		}
+	}
+
+	if m.username != "" {
+		auth := smtp.PlainAuth("", m.username, m.password, m.host)
+		if err := client.Auth(auth); err != nil {
+			return newMailerError("failed to authenticate with smtp server", err)
+		}
+	}
+
+	if err := client.Mail(sender.Address); err != nil {
+		return newMailerError("smtp server rejected sender", err)
+	}
+	if err := client.Rcpt(msg.To); err != nil {
+		return newMailerError("smtp server rejected recipient", err)
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return newMailerError("failed to open smtp data stream", err)
+	}
+	if _, err := w.Write(raw); err != nil {
+		return newMailerError("failed to write email", err)
+	}
+	if err := w.Close(); err != nil {
+		return newMailerError("smtp server rejected email", err)
+	}
+
+	return client.Quit()
+}
@@ -0,0 +1,114 @@
+package mailer
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+)
+
+// DefaultLocale is used when a template is not available in the requested
+// locale.
+const DefaultLocale = "pt-BR"
+
+type Template string
+
+const (
+	TemplatePasswordReset     Template = "password_reset"
+	TemplateEmailVerification Template = "email_verification"
+	TemplateEmailChange       Template = "email_change"
+	TemplateEmailChangeNotice Template = "email_change_notice"
+	TemplateDataExportReady   Template = "data_export_ready"
+)
+
+type PasswordResetData struct {
+	Name             string
+	Link             string
+	ExpiresInMinutes int
+}
+
+type EmailVerificationData struct {
+	Name           string
+	Link           string
+	ExpiresInHours int
+}
+
+type EmailChangeData struct {
+	Name           string
+	Link           string
+	ExpiresInHours int
+}
+
+type EmailChangeNoticeData struct {
+	Name     string
+	NewEmail string
+}
+
+type DataExportReadyData struct {
+	Name           string
+	Link           string
+	ExpiresInHours int
+}
+
+//go:embed templates
+var templatesFS embed.FS
+
+// NewMessage renders tmpl for the given locale, falling back to DefaultLocale.
+// Each template lives in templates/<locale>/<name>.txt and an optional
+// <name>.html; the text file must define a "subject" block.
+func NewMessage(to, locale string, tmpl Template, data any) (Message, error) {
+	locale = resolveLocale(locale, tmpl)
+
+	textTmpl, err := texttemplate.ParseFS(templatesFS, templatePath(locale, tmpl, "txt"))
+	if err != nil {
+		return Message{}, newMailerError("failed to parse email template", err)
+	}
+
+	var subject, text bytes.Buffer
+	if err := textTmpl.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return Message{}, newMailerError("failed to render email subject", err)
+	}
+	if err := textTmpl.Execute(&text, data); err != nil {
+		return Message{}, newMailerError("failed to render email text", err)
+	}
+
+	msg := Message{
+		To:      to,
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    strings.TrimSpace(text.String()),
+	}
+
+	htmlPath := templatePath(locale, tmpl, "html")
+	if _, err := templatesFS.Open(htmlPath); err != nil {
+		return msg, nil
+	}
+
+	htmlTmpl, err := htmltemplate.ParseFS(templatesFS, htmlPath)
+	if err != nil {
+		return Message{}, newMailerError("failed to parse email template", err)
+	}
+
+	var html bytes.Buffer
+	if err := htmlTmpl.Execute(&html, data); err != nil {
+		return Message{}, newMailerError("failed to render email html", err)
+	}
+	msg.HTML = html.String()
+
+	return msg, nil
+}
+
+func resolveLocale(locale string, tmpl Template) string {
+	if locale == "" {
+		return DefaultLocale
+	}
+	if _, err := templatesFS.Open(templatePath(locale, tmpl, "txt")); err != nil {
+		return DefaultLocale
+	}
+	return locale
+}
+
+func templatePath(locale string, tmpl Template, ext string) string {
+	return fmt.Sprintf("templates/%s/%s.%s", locale, tmpl, ext)
+}
@@ -0,0 +1,63 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type cacheEntry struct {
+	value     any
+	expiresAt time.Time
+}
+
+// Cache keeps values for a fixed time to live. Values are shared with every
+// caller, not copied.
+type Cache struct {
+	mu      sync.RWMutex
+	ttl     time.Duration
+	entries map[string]cacheEntry
+}
+
+func NewCache(ttl time.Duration) *Cache {
+	return &Cache{ttl: ttl, entries: make(map[string]cacheEntry)}
+}
+
+func (c *Cache) Get(_ context.Context, key string) (any, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	entry, ok := c.entries[key]
+	if !ok || !entry.expiresAt.After(time.Now()) {
+		return nil, false
+	}
+
+	return entry.value, true
+}
+
+func (c *Cache) Set(_ context.Context, key string, value any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if len(c.entries) >= sweepThreshold {
+		c.sweep(now)
+	}
+
+	c.entries[key] = cacheEntry{value: value, expiresAt: now.Add(c.ttl)}
+}
+
+func (c *Cache) Clear(_ context.Context) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = make(map[string]cacheEntry)
+}
+
+func (c *Cache) sweep(now time.Time) {
+	for key, entry := range c.entries {
+		if !entry.expiresAt.After(now) {
+			delete(c.entries, key)
+		}
+	}
+}
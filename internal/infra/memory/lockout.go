@@ -0,0 +1,115 @@
+// Package memory holds process-local implementations of stores that can also
+// be backed by Postgres. They are meant for single-instance deployments and
+// development.
+package memory
+
+import (
+	"context"
+	"msn/internal/modules/lockout"
+	"sync"
+	"time"
+)
+
+// sweepThreshold is the number of tracked keys above which expired entries
+// are dropped on the next write.
+const sweepThreshold = 10000
+
+type lockoutEntry struct {
+	attempts  lockout.Attempts
+	expiresAt time.Time
+}
+
+type LockoutStore struct {
+	mu      sync.Mutex
+	entries map[string]*lockoutEntry
+}
+
+func NewLockoutStore() *LockoutStore {
+	return &LockoutStore{entries: make(map[string]*lockoutEntry)}
+}
+
+func (s *LockoutStore) Get(_ context.Context, key string) (*lockout.Attempts, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok {
+		return nil, nil
+	}
+
+	if !entry.expiresAt.After(time.Now()) {
+		delete(s.entries, key)
+		return nil, nil
+	}
+
+	attempts := entry.attempts
+	return &attempts, nil
+}
+
+func (s *LockoutStore) Increment(_ context.Context, key string, window time.Duration) (*lockout.Attempts, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if len(s.entries) >= sweepThreshold {
+		s.sweep(now)
+	}
+
+	entry, ok := s.entries[key]
+	if !ok || !entry.attempts.InWindow(now, window) {
+		var lockedUntil *time.Time
+		if ok {
+			lockedUntil = entry.attempts.LockedUntil
+		}
+		entry = &lockoutEntry{attempts: lockout.Attempts{
+			Key:            key,
+			FirstFailureAt: now,
+			LockedUntil:    lockedUntil,
+		}}
+		s.entries[key] = entry
+	}
+
+	entry.attempts.Failures++
+	entry.attempts.LastFailureAt = now
+	entry.expiresAt = entry.attempts.FirstFailureAt.Add(window)
+	if entry.attempts.IsLocked(now) && entry.attempts.LockedUntil.After(entry.expiresAt) {
+		entry.expiresAt = *entry.attempts.LockedUntil
+	}
+
+	attempts := entry.attempts
+	return &attempts, nil
+}
+
+func (s *LockoutStore) Lock(_ context.Context, key string, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok {
+		return nil
+	}
+
+	entry.attempts.LockedUntil = &until
+	if until.After(entry.expiresAt) {
+		entry.expiresAt = until
+	}
+
+	return nil
+}
+
+func (s *LockoutStore) Reset(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, key)
+
+	return nil
+}
+
+func (s *LockoutStore) sweep(now time.Time) {
+	for key, entry := range s.entries {
+		if !entry.expiresAt.After(now) {
+			delete(s.entries, key)
+		}
+	}
+}
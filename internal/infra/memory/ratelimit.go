@@ -0,0 +1,51 @@
+package memory
+
+import (
+	"context"
+	"msn/internal/modules/ratelimit"
+	"sync"
+	"time"
+)
+
+type rateLimitEntry struct {
+	bucket    *ratelimit.Bucket
+	expiresAt time.Time
+}
+
+type RateLimitStore struct {
+	mu      sync.Mutex
+	entries map[string]*rateLimitEntry
+}
+
+func NewRateLimitStore() *RateLimitStore {
+	return &RateLimitStore{entries: make(map[string]*rateLimitEntry)}
+}
+
+func (s *RateLimitStore) Take(_ context.Context, key string, limit ratelimit.Limit) (*ratelimit.Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if len(s.entries) >= sweepThreshold {
+		s.sweep(now)
+	}
+
+	entry, ok := s.entries[key]
+	if !ok || !entry.expiresAt.After(now) {
+		entry = &rateLimitEntry{bucket: ratelimit.NewBucket(key, limit, now)}
+		s.entries[key] = entry
+	}
+
+	res := entry.bucket.Take(limit, now)
+	entry.expiresAt = entry.bucket.FullAt(limit)
+
+	return &res, nil
+}
+
+func (s *RateLimitStore) sweep(now time.Time) {
+	for key, entry := range s.entries {
+		if !entry.expiresAt.After(now) {
+			delete(s.entries, key)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package memory
+
+import (
+	"context"
+	"msn/internal/modules/revocation"
+	"sync"
+	"time"
+)
+
+type RevocationStore struct {
+	mu      sync.RWMutex
+	entries map[string]time.Time
+}
+
+func NewRevocationStore() *RevocationStore {
+	return &RevocationStore{entries: make(map[string]time.Time)}
+}
+
+func (s *RevocationStore) Add(_ context.Context, entry revocation.Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if len(s.entries) >= sweepThreshold {
+		s.sweep(now)
+	}
+
+	if current, ok := s.entries[entry.Key]; !ok || entry.ExpiresAt.After(current) {
+		s.entries[entry.Key] = entry.ExpiresAt
+	}
+
+	return nil
+}
+
+func (s *RevocationStore) Contains(_ context.Context, keys ...string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := time.Now()
+	for _, key := range keys {
+		if expiresAt, ok := s.entries[key]; ok && expiresAt.After(now) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (s *RevocationStore) sweep(now time.Time) {
+	for key, expiresAt := range s.entries {
+		if !expiresAt.After(now) {
+			delete(s.entries, key)
+		}
+	}
+}
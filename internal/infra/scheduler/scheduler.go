@@ -0,0 +1,44 @@
+package scheduler
+
+import (
+	"context"
+	"msn/internal/infra/logging"
+	"time"
+)
+
+// Every runs job in a new goroutine right away and then every interval, until
+// ctx is done. A failed run is logged and retried on the next tick.
+func Every(ctx context.Context, name string, interval time.Duration, job func(context.Context) error) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			run(ctx, name, job)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func run(ctx context.Context, name string, job func(context.Context) error) {
+	logger := logging.FromContext(ctx)
+	start := time.Now()
+
+	if err := job(ctx); err != nil {
+		logger.ErrorContext(ctx, "job_failed",
+			"job", name,
+			"error", err,
+		)
+		return
+	}
+
+	logger.DebugContext(ctx, "job_finished",
+		"job", name,
+		"duration", time.Since(start),
+	)
+}
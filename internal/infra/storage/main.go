@@ -2,9 +2,13 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"mime/multipart"
+	"net/url"
+	"time"
 
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
@@ -27,6 +31,18 @@ func NewStorageClient(endpoint, accessKey, secretKey string) *StorageClient {
 }
 
 func (c *StorageClient) UploadFile(bucketName, objectName string, fileHeader *multipart.FileHeader) (string, error) {
+	file, err := fileHeader.Open()
+	if err != nil {
+		return "", fmt.Errorf("failed to open uploaded file: %w", err)
+	}
+	defer file.Close()
+
+	return c.Upload(bucketName, objectName, file, fileHeader.Size, fileHeader.Header.Get("Content-Type"))
+}
+
+// Upload stores size bytes read from r, creating the bucket if needed, and
+// returns the key of the object.
+func (c *StorageClient) Upload(bucketName, objectName string, r io.Reader, size int64, contentType string) (string, error) {
 	ctx := context.Background()
 	exists, err := c.client.BucketExists(ctx, bucketName)
 	if err != nil {
@@ -39,14 +55,8 @@ func (c *StorageClient) UploadFile(bucketName, objectName string, fileHeader *mu
 		}
 	}
 
-	file, err := fileHeader.Open()
-	if err != nil {
-		return "", fmt.Errorf("failed to open uploaded file: %w", err)
-	}
-	defer file.Close()
-
-	info, err := c.client.PutObject(ctx, bucketName, objectName, file, fileHeader.Size, minio.PutObjectOptions{
-		ContentType: fileHeader.Header.Get("Content-Type"),
+	info, err := c.client.PutObject(ctx, bucketName, objectName, r, size, minio.PutObjectOptions{
+		ContentType: contentType,
 	})
 	if err != nil {
 		return "", fmt.Errorf("failed to upload object %w", err)
@@ -54,3 +64,48 @@ func (c *StorageClient) UploadFile(bucketName, objectName string, fileHeader *mu
 
 	return info.Key, nil
 }
+
+// ErrObjectNotFound is returned by GetFile when the object does not exist.
+var ErrObjectNotFound = errors.New("object not found")
+
+// GetFile opens an object for reading. The caller must close it.
+func (c *StorageClient) GetFile(bucketName, objectName string) (io.ReadCloser, error) {
+	obj, err := c.client.GetObject(context.Background(), bucketName, objectName, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object %s: %w", objectName, err)
+	}
+
+	// GetObject is lazy; Stat surfaces a missing object before any read.
+	if _, err := obj.Stat(); err != nil {
+		obj.Close()
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return nil, fmt.Errorf("failed to get object %s: %w", objectName, ErrObjectNotFound)
+		}
+		return nil, fmt.Errorf("failed to get object %s: %w", objectName, err)
+	}
+
+	return obj, nil
+}
+
+// PresignedURL returns a link that downloads the object as filename without
+// credentials until expiry, which can be at most 7 days.
+func (c *StorageClient) PresignedURL(bucketName, objectName, filename string, expiry time.Duration) (string, error) {
+	params := url.Values{}
+	params.Set("response-content-disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	u, err := c.client.PresignedGetObject(context.Background(), bucketName, objectName, expiry, params)
+	if err != nil {
+		return "", fmt.Errorf("failed to presign object %s: %w", objectName, err)
+	}
+
+	return u.String(), nil
+}
+
+func (c *StorageClient) RemoveFile(bucketName, objectName string) error {
+	err := c.client.RemoveObject(context.Background(), bucketName, objectName, minio.RemoveObjectOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to remove object %s: %w", objectName, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,84 @@
+package auth
+
+import (
+	"context"
+	"msn/internal/infra/logging"
+	"msn/pkg/common/dto"
+	"msn/pkg/common/fault"
+)
+
+// DeleteAccount deletes the account of the logged in user, who must confirm
+// the password, and ends all of its sessions. The account can be restored by
+// an admin until it is anonymized at the end of the grace period.
+func (s *service) DeleteAccount(ctx context.Context, input dto.DeleteAccountRequest) error {
+	logger := logging.FromContext(ctx)
+
+	c, err := claimsFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	if input.Password == "" {
+		return fault.NewBadRequest("password is required")
+	}
+
+	u, err := s.userRepo.GetByID(ctx, c.Subject)
+	if err != nil {
+		logger.ErrorContext(
+			ctx, "db_error",
+			"operation", "userRepo.GetByID",
+			"user_id", c.Subject,
+			"error", err,
+		)
+		return fault.NewInternalServerError("failed to delete account")
+	}
+	if u == nil {
+		return fault.NewNotFound("user not found")
+	}
+
+	if !u.PasswordMatches(input.Password) {
+		logger.WarnContext(
+			ctx, "security_event",
+			"event", "account_deletion_wrong_password",
+			"user_id", u.ID(),
+		)
+		return fault.NewForbidden("invalid password")
+	}
+
+	if err := u.Delete(); err != nil {
+		return err
+	}
+
+	ok, err := s.userRepo.Delete(ctx, u)
+	if err != nil {
+		logger.ErrorContext(
+			ctx, "db_error",
+			"operation", "userRepo.Delete",
+			"user_id", u.ID(),
+			"error", err,
+		)
+		return fault.NewInternalServerError("failed to delete account")
+	}
+	if !ok {
+		return fault.NewConflict("user already deleted")
+	}
+
+	if err := s.sessionService.DeactivateAllSessions(ctx, u.ID()); err != nil {
+		logger.ErrorContext(
+			ctx, "sessionServiceError",
+			"operation", "DeactivateAllSessions",
+			"user_id", u.ID(),
+			"error", err.Error(),
+		)
+		return fault.NewInternalServerError("failed to revoke sessions")
+	}
+
+	logger.InfoContext(
+		ctx, "security_event",
+		"event", "account_deleted",
+		"user_id", u.ID(),
+		"session_id", c.SessionID,
+	)
+
+	return nil
+}
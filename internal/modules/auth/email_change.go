@@ -0,0 +1,272 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"msn/internal/config"
+	"msn/internal/infra/logging"
+	"msn/internal/infra/mailer"
+	"msn/internal/modules/user"
+	"msn/internal/modules/usertoken"
+	"msn/pkg/common/dto"
+	"msn/pkg/common/fault"
+	"msn/pkg/utils/crypto"
+	"msn/pkg/utils/dbutil"
+	"net/url"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const emailChangeTokenTTL = 24 * time.Hour
+
+// RequestEmailChange keeps the new email as pending and mails a confirmation
+// link to it, along with a notice to the current email. The email used to log
+// in only changes once the link is opened.
+func (s *service) RequestEmailChange(ctx context.Context, input dto.ChangeEmailRequest) error {
+	logger := logging.FromContext(ctx)
+
+	c, err := claimsFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	if input.Password == "" {
+		return fault.NewBadRequest("password is required")
+	}
+
+	u, err := s.userRepo.GetByID(ctx, c.Subject)
+	if err != nil {
+		logger.ErrorContext(
+			ctx, "db_error",
+			"operation", "userRepo.GetByID",
+			"user_id", c.Subject,
+			"error", err,
+		)
+		return fault.NewInternalServerError("failed to request email change")
+	}
+	if u == nil || u.DeletedAt() != nil {
+		return fault.NewNotFound("user not found")
+	}
+
+	if !u.PasswordMatches(input.Password) {
+		logger.WarnContext(
+			ctx, "security_event",
+			"event", "email_change_wrong_password",
+			"user_id", u.ID(),
+		)
+		return fault.NewForbidden("invalid password")
+	}
+
+	currentEmail := u.Email()
+	if err := u.RequestEmailChange(input.Email); err != nil {
+		return err
+	}
+	newEmail := *u.PendingEmail()
+
+	existing, err := s.userRepo.GetByEmail(ctx, newEmail)
+	if err != nil {
+		logger.ErrorContext(
+			ctx, "db_error",
+			"operation", "userRepo.GetByEmail",
+			"error", err,
+		)
+		return fault.NewInternalServerError("failed to request email change")
+	}
+	if existing != nil {
+		return fault.NewConflict("email already taken")
+	}
+
+	if err := s.userRepo.SavePendingEmail(ctx, u); err != nil {
+		logger.ErrorContext(
+			ctx, "db_error",
+			"operation", "userRepo.SavePendingEmail",
+			"user_id", u.ID(),
+			"error", err,
+		)
+		return fault.NewInternalServerError("failed to request email change")
+	}
+
+	err = s.userTokenRepo.InvalidateAll(ctx, u.ID(), usertoken.PurposeEmailChange)
+	if err != nil {
+		logger.ErrorContext(
+			ctx, "db_error",
+			"operation", "userTokenRepo.InvalidateAll",
+			"user_id", u.ID(),
+			"error", err,
+		)
+		return fault.NewInternalServerError("failed to request email change")
+	}
+
+	token, plain, err := usertoken.New(u.ID(), usertoken.PurposeEmailChange, emailChangeTokenTTL)
+	if err != nil {
+		logger.ErrorContext(ctx, "email_change_token_generation_failed", "error", err)
+		return fault.NewInternalServerError("failed to request email change")
+	}
+	token.SessionID = &c.SessionID
+
+	if err := s.userTokenRepo.Create(ctx, token); err != nil {
+		logger.ErrorContext(
+			ctx, "db_error",
+			"operation", "userTokenRepo.Create",
+			"user_id", u.ID(),
+			"error", err,
+		)
+		return fault.NewInternalServerError("failed to request email change")
+	}
+
+	if err := s.sendEmailChangeEmail(ctx, u, newEmail, plain); err != nil {
+		logger.ErrorContext(
+			ctx, "mailer_error",
+			"user_id", u.ID(),
+			"error", err,
+		)
+		return fault.NewInternalServerError("failed to send confirmation email")
+	}
+
+	// The change is already pending; a missing notice must not stop it.
+	if err := s.sendEmailChangeNotice(ctx, u, currentEmail, newEmail); err != nil {
+		logger.ErrorContext(
+			ctx, "mailer_error",
+			"user_id", u.ID(),
+			"error", err,
+		)
+	}
+
+	logger.InfoContext(
+		ctx, "security_event",
+		"event", "email_change_requested",
+		"user_id", u.ID(),
+		"session_id", c.SessionID,
+	)
+
+	return nil
+}
+
+// ConfirmEmailChange swaps the email of the user for the pending one and ends
+// every session but the one the change was requested from.
+func (s *service) ConfirmEmailChange(ctx context.Context, plain string) error {
+	logger := logging.FromContext(ctx)
+
+	if plain == "" {
+		return fault.NewBadRequest("token is required")
+	}
+
+	token, err := s.userTokenRepo.GetByHash(ctx, usertoken.PurposeEmailChange, crypto.HashToken(plain))
+	if err != nil {
+		logger.ErrorContext(
+			ctx, "db_error",
+			"operation", "userTokenRepo.GetByHash",
+			"error", err,
+		)
+		return fault.NewInternalServerError("failed to confirm email change")
+	}
+
+	if token == nil || !token.IsUsable() {
+		logger.DebugContext(ctx, "invalid_email_change_token", "found", token != nil)
+		return fault.NewBadRequest("invalid or expired email change token")
+	}
+
+	ok, err := s.userTokenRepo.Consume(ctx, token.ID)
+	if err != nil {
+		logger.ErrorContext(
+			ctx, "db_error",
+			"operation", "userTokenRepo.Consume",
+			"token_id", token.ID,
+			"error", err,
+		)
+		return fault.NewInternalServerError("failed to confirm email change")
+	}
+	if !ok {
+		logger.DebugContext(ctx, "email_change_token_already_used", "token_id", token.ID)
+		return fault.NewBadRequest("invalid or expired email change token")
+	}
+
+	ok, err = s.userRepo.ConfirmPendingEmail(ctx, token.UserID)
+	if err != nil {
+		logger.ErrorContext(
+			ctx, "db_error",
+			"operation", "userRepo.ConfirmPendingEmail",
+			"user_id", token.UserID,
+			"error", err,
+		)
+
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			field := dbutil.ExtractFieldFromDetail(pqErr.Detail)
+			logger.WarnContext(ctx, "unique_constraint_violation",
+				"field", field,
+				"user_id", token.UserID,
+			)
+			return fault.NewConflict(fmt.Sprintf("%s already taken", field))
+		}
+
+		return fault.NewInternalServerError("failed to confirm email change")
+	}
+	if !ok {
+		logger.DebugContext(ctx, "email_change_not_pending", "user_id", token.UserID)
+		return fault.NewBadRequest("invalid or expired email change token")
+	}
+
+	if err := s.userTokenRepo.InvalidateAll(ctx, token.UserID, usertoken.PurposeEmailChange); err != nil {
+		logger.ErrorContext(
+			ctx, "db_error",
+			"operation", "userTokenRepo.InvalidateAll",
+			"user_id", token.UserID,
+			"error", err,
+		)
+	}
+
+	keep := ""
+	if token.SessionID != nil {
+		keep = *token.SessionID
+	}
+
+	revoked, err := s.sessionService.RevokeOtherSessions(ctx, token.UserID, keep)
+	if err != nil {
+		logger.ErrorContext(
+			ctx, "sessionServiceError",
+			"operation", "RevokeOtherSessions",
+			"user_id", token.UserID,
+			"revoked", revoked,
+			"error", err.Error(),
+		)
+		return fault.NewInternalServerError("failed to revoke sessions")
+	}
+
+	logger.InfoContext(
+		ctx, "security_event",
+		"event", "email_changed",
+		"user_id", token.UserID,
+		"session_id", keep,
+		"revoked", revoked,
+	)
+
+	return nil
+}
+
+func (s *service) sendEmailChangeEmail(ctx context.Context, u *user.User, newEmail, token string) error {
+	msg, err := mailer.NewMessage(newEmail, mailer.DefaultLocale, mailer.TemplateEmailChange, mailer.EmailChangeData{
+		Name:           u.Name(),
+		Link:           fmt.Sprintf("%s/api/v1/auth/email/confirm?token=%s", config.GetConfig().AppURL, url.QueryEscape(token)),
+		ExpiresInHours: int(emailChangeTokenTTL.Hours()),
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(ctx, msg)
+}
+
+func (s *service) sendEmailChangeNotice(ctx context.Context, u *user.User, currentEmail, newEmail string) error {
+	msg, err := mailer.NewMessage(currentEmail, mailer.DefaultLocale, mailer.TemplateEmailChangeNotice, mailer.EmailChangeNoticeData{
+		Name:     u.Name(),
+		NewEmail: newEmail,
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(ctx, msg)
+}
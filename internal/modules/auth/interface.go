@@ -7,9 +7,19 @@ import (
 )
 
 type AuthService interface {
-	Login(ctx context.Context, email, password string) (*dto.LoginResponse, error)
+	Login(ctx context.Context, email, password string, device dto.DeviceInfo) (*dto.LoginResponse, error)
+	VerifyMFA(ctx context.Context, input dto.MFAVerifyRequest, device dto.DeviceInfo) (*dto.LoginResponse, error)
 	Logout(ctx context.Context) error
-	// RenewAccessToken(ctx context.Context, refreshToken string) (*dto.RenewTokenResponse, error)
+	ListSessions(ctx context.Context) ([]*dto.SessionResponse, error)
+	RevokeSession(ctx context.Context, sessionID string) error
+	RevokeOtherSessions(ctx context.Context) (*dto.RevokeSessionsResponse, error)
+	RenewAccessToken(ctx context.Context, refreshToken string, device dto.DeviceInfo) (*dto.RenewTokenResponse, error)
+	ForgotPassword(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, input dto.ResetPasswordRequest) error
+	ChangePassword(ctx context.Context, input dto.ChangePasswordRequest) error
+	DeleteAccount(ctx context.Context, input dto.DeleteAccountRequest) error
+	RequestEmailChange(ctx context.Context, input dto.ChangeEmailRequest) error
+	ConfirmEmailChange(ctx context.Context, token string) error
 }
 
 type TokenProvider interface {
@@ -0,0 +1,155 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"msn/internal/infra/logging"
+	"msn/internal/modules/user"
+	"msn/pkg/common/dto"
+	"msn/pkg/common/fault"
+	"msn/pkg/common/valueobjects"
+	"msn/pkg/utils/crypto"
+	"msn/pkg/utils/validation"
+)
+
+// DefaultPasswordHistory is how many of the latest passwords of a user,
+// the current one included, cannot be reused when none is configured.
+const DefaultPasswordHistory = 5
+
+// ChangePassword replaces the password of the logged in user, who must know
+// the current one, and ends every other session of the user.
+func (s *service) ChangePassword(ctx context.Context, input dto.ChangePasswordRequest) error {
+	logger := logging.FromContext(ctx)
+
+	c, err := claimsFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	if input.CurrentPassword == "" {
+		return fault.NewBadRequest("current password is required")
+	}
+	if input.Password != input.ConfirmPassword {
+		return fault.NewBadRequest("passwords do not match")
+	}
+
+	u, err := s.userRepo.GetByID(ctx, c.Subject)
+	if err != nil {
+		logger.ErrorContext(
+			ctx, "db_error",
+			"operation", "userRepo.GetByID",
+			"user_id", c.Subject,
+			"error", err,
+		)
+		return fault.NewInternalServerError("failed to change password")
+	}
+	if u == nil || u.DeletedAt() != nil {
+		return fault.NewNotFound("user not found")
+	}
+
+	if !u.PasswordMatches(input.CurrentPassword) {
+		logger.WarnContext(
+			ctx, "security_event",
+			"event", "password_change_wrong_password",
+			"user_id", u.ID(),
+		)
+		return fault.NewForbidden("invalid password")
+	}
+
+	password, err := s.newPassword(ctx, u, input.Password)
+	if err != nil {
+		return err
+	}
+
+	if err := s.storePassword(ctx, u.ID(), password); err != nil {
+		return err
+	}
+
+	revoked, err := s.sessionService.RevokeOtherSessions(ctx, u.ID(), c.SessionID)
+	if err != nil {
+		logger.ErrorContext(
+			ctx, "sessionServiceError",
+			"operation", "RevokeOtherSessions",
+			"user_id", u.ID(),
+			"revoked", revoked,
+			"error", err.Error(),
+		)
+		return fault.NewInternalServerError("failed to revoke sessions")
+	}
+
+	logger.InfoContext(
+		ctx, "security_event",
+		"event", "password_changed",
+		"user_id", u.ID(),
+		"session_id", c.SessionID,
+		"revoked", revoked,
+	)
+
+	return nil
+}
+
+// newPassword checks plain against the password rules and the history of
+// the user, and hashes it. Every flow that sets a password goes through it.
+func (s *service) newPassword(ctx context.Context, u *user.User, plain string) (valueobjects.Password, error) {
+	if err := validation.ValidatePassword(plain); err != nil {
+		return valueobjects.Password{}, fault.NewBadRequest(err.Error())
+	}
+
+	reused, err := s.passwordReused(ctx, u.ID(), plain)
+	if err != nil {
+		logging.FromContext(ctx).ErrorContext(
+			ctx, "db_error",
+			"operation", "userRepo.GetPasswordHistory",
+			"user_id", u.ID(),
+			"error", err,
+		)
+		return valueobjects.Password{}, fault.NewInternalServerError("failed to update password")
+	}
+	if reused || u.PasswordMatches(plain) {
+		return valueobjects.Password{}, fault.NewBadRequest(fmt.Sprintf("password must differ from the last %d passwords", s.passwordHistory))
+	}
+
+	password, err := valueobjects.NewPassword(plain)
+	if err != nil {
+		return valueobjects.Password{}, fault.NewBadRequest(err.Error())
+	}
+
+	return password, nil
+}
+
+// storePassword replaces the password of the user, moving the current one to
+// the history.
+func (s *service) storePassword(ctx context.Context, userID string, password valueobjects.Password) error {
+	if err := s.userRepo.ChangePassword(ctx, userID, password.Hash, s.passwordHistory-1); err != nil {
+		logging.FromContext(ctx).ErrorContext(
+			ctx, "db_error",
+			"operation", "userRepo.ChangePassword",
+			"user_id", userID,
+			"error", err,
+		)
+		return fault.NewInternalServerError("failed to update password")
+	}
+
+	return nil
+}
+
+// passwordReused reports whether plain is one of the previous passwords the
+// user cannot reuse. The current password is checked by the caller.
+func (s *service) passwordReused(ctx context.Context, userID, plain string) (bool, error) {
+	if s.passwordHistory == 1 {
+		return false, nil
+	}
+
+	hashes, err := s.userRepo.GetPasswordHistory(ctx, userID, s.passwordHistory-1)
+	if err != nil {
+		return false, err
+	}
+
+	for _, hash := range hashes {
+		if crypto.PasswordMatches(plain, hash) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
@@ -0,0 +1,201 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"msn/internal/config"
+	"msn/internal/infra/logging"
+	"msn/internal/infra/mailer"
+	"msn/internal/modules/user"
+	"msn/internal/modules/usertoken"
+	"msn/pkg/common/dto"
+	"msn/pkg/common/fault"
+	"msn/pkg/utils/crypto"
+	"msn/pkg/utils/validation"
+	"time"
+)
+
+const passwordResetTokenTTL = 30 * time.Minute
+
+// ForgotPassword issues a reset token for the account and mails it. It
+// returns nil whether or not the email belongs to an account, and the token
+// is issued in the background, so neither the answer nor its timing reveals
+// which emails are registered.
+func (s *service) ForgotPassword(ctx context.Context, email string) error {
+	logger := logging.FromContext(ctx)
+
+	logger.DebugContext(ctx, "password_reset_requested", "email", email)
+
+	if err := validation.ValidateEmail(email); err != nil {
+		return fault.NewBadRequest(err.Error())
+	}
+
+	u, err := s.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		logger.ErrorContext(
+			ctx, "db_error",
+			"operation", "userRepo.GetByEmail",
+			"error", err,
+		)
+		return fault.NewInternalServerError("failed to request password reset")
+	}
+
+	if u == nil || u.DeletedAt() != nil {
+		logger.DebugContext(ctx, "password_reset_unknown_user", "email", email)
+		return nil
+	}
+
+	// Detached from the request, which ends before the email is sent.
+	go s.issuePasswordReset(context.WithoutCancel(ctx), u)
+
+	return nil
+}
+
+// issuePasswordReset replaces the reset tokens of the user with a new one and
+// mails it. Failures are only logged, the caller already answered.
+func (s *service) issuePasswordReset(ctx context.Context, u *user.User) {
+	logger := logging.FromContext(ctx)
+
+	err := s.userTokenRepo.InvalidateAll(ctx, u.ID(), usertoken.PurposePasswordReset)
+	if err != nil {
+		logger.ErrorContext(
+			ctx, "db_error",
+			"operation", "userTokenRepo.InvalidateAll",
+			"user_id", u.ID(),
+			"error", err,
+		)
+		return
+	}
+
+	token, plain, err := usertoken.New(u.ID(), usertoken.PurposePasswordReset, passwordResetTokenTTL)
+	if err != nil {
+		logger.ErrorContext(ctx, "password_reset_token_generation_failed", "error", err)
+		return
+	}
+
+	if err := s.userTokenRepo.Create(ctx, token); err != nil {
+		logger.ErrorContext(
+			ctx, "db_error",
+			"operation", "userTokenRepo.Create",
+			"user_id", u.ID(),
+			"error", err,
+		)
+		return
+	}
+
+	if err := s.sendPasswordResetEmail(ctx, u, plain); err != nil {
+		logger.ErrorContext(
+			ctx, "mailer_error",
+			"user_id", u.ID(),
+			"error", err,
+		)
+		return
+	}
+
+	logger.InfoContext(ctx, "password_reset_token_issued", "user_id", u.ID())
+}
+
+// ResetPassword sets a new password with a token mailed by ForgotPassword. The
+// password follows the same rules and history as ChangePassword.
+func (s *service) ResetPassword(ctx context.Context, input dto.ResetPasswordRequest) error {
+	logger := logging.FromContext(ctx)
+
+	if input.Token == "" {
+		return fault.NewBadRequest("token is required")
+	}
+	if input.Password != input.ConfirmPassword {
+		return fault.NewBadRequest("passwords do not match")
+	}
+
+	token, err := s.userTokenRepo.GetByHash(ctx, usertoken.PurposePasswordReset, crypto.HashToken(input.Token))
+	if err != nil {
+		logger.ErrorContext(
+			ctx, "db_error",
+			"operation", "userTokenRepo.GetByHash",
+			"error", err,
+		)
+		return fault.NewInternalServerError("failed to reset password")
+	}
+
+	if token == nil || !token.IsUsable() {
+		logger.DebugContext(ctx, "invalid_password_reset_token", "found", token != nil)
+		return fault.NewBadRequest("invalid or expired reset token")
+	}
+
+	u, err := s.userRepo.GetByID(ctx, token.UserID)
+	if err != nil {
+		logger.ErrorContext(
+			ctx, "db_error",
+			"operation", "userRepo.GetByID",
+			"user_id", token.UserID,
+			"error", err,
+		)
+		return fault.NewInternalServerError("failed to reset password")
+	}
+	if u == nil {
+		logger.DebugContext(ctx, "password_reset_user_gone", "user_id", token.UserID)
+		return fault.NewBadRequest("invalid or expired reset token")
+	}
+
+	// Checked before the token is used, so the user can pick another
+	// password with the same link.
+	password, err := s.newPassword(ctx, u, input.Password)
+	if err != nil {
+		return err
+	}
+
+	ok, err := s.userTokenRepo.Consume(ctx, token.ID)
+	if err != nil {
+		logger.ErrorContext(
+			ctx, "db_error",
+			"operation", "userTokenRepo.Consume",
+			"token_id", token.ID,
+			"error", err,
+		)
+		return fault.NewInternalServerError("failed to reset password")
+	}
+	if !ok {
+		logger.DebugContext(ctx, "password_reset_token_already_used", "token_id", token.ID)
+		return fault.NewBadRequest("invalid or expired reset token")
+	}
+
+	if err := s.storePassword(ctx, u.ID(), password); err != nil {
+		return err
+	}
+
+	if err := s.userTokenRepo.InvalidateAll(ctx, token.UserID, usertoken.PurposePasswordReset); err != nil {
+		logger.ErrorContext(
+			ctx, "db_error",
+			"operation", "userTokenRepo.InvalidateAll",
+			"user_id", token.UserID,
+			"error", err,
+		)
+	}
+
+	if err := s.sessionService.DeactivateAllSessions(ctx, token.UserID); err != nil {
+		logger.ErrorContext(
+			ctx, "sessionServiceError",
+			"operation", "DeactivateAllSessions",
+			"user_id", token.UserID,
+			"error", err.Error(),
+		)
+		return fault.NewInternalServerError("failed to revoke sessions")
+	}
+
+	logger.InfoContext(ctx, "password_reset_successful", "user_id", token.UserID)
+
+	return nil
+}
+
+func (s *service) sendPasswordResetEmail(ctx context.Context, u *user.User, token string) error {
+	msg, err := mailer.NewMessage(u.Email(), mailer.DefaultLocale, mailer.TemplatePasswordReset, mailer.PasswordResetData{
+		Name:             u.Name(),
+		Link:             fmt.Sprintf("%s/reset-password?token=%s", config.GetConfig().FrontendURL, token),
+		ExpiresInMinutes: int(passwordResetTokenTTL.Minutes()),
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(ctx, msg)
+}
@@ -5,34 +5,61 @@ import (
 	"msn/internal/infra/http/middlewares"
 	"msn/internal/infra/jwt"
 	"msn/internal/infra/logging"
+	"msn/internal/infra/mailer"
+	"msn/internal/modules/lockout"
+	"msn/internal/modules/mfa"
 	"msn/internal/modules/session"
 	"msn/internal/modules/user"
+	"msn/internal/modules/usertoken"
 	"msn/pkg/common/dto"
 	"msn/pkg/common/fault"
+	"msn/pkg/utils/crypto"
 	"net/http"
 )
 
 type ServiceConfig struct {
 	UserRepo       user.UserRepository
+	UserTokenRepo  usertoken.Repository
 	SessionService session.SessionService
+	MFAService     mfa.Service
+	LockoutService lockout.Service
 	TokenProvider  jwt.JWTProvider
+	Mailer         mailer.Mailer
+	// PasswordHistory is how many of the latest passwords cannot be reused,
+	// DefaultPasswordHistory when not positive.
+	PasswordHistory int
 }
 
 type service struct {
-	userRepo       user.UserRepository
-	sessionService session.SessionService
-	tokenProvider  jwt.JWTProvider
+	userRepo        user.UserRepository
+	userTokenRepo   usertoken.Repository
+	sessionService  session.SessionService
+	mfaService      mfa.Service
+	lockoutService  lockout.Service
+	tokenProvider   jwt.JWTProvider
+	mailer          mailer.Mailer
+	passwordHistory int
 }
 
 func NewService(c ServiceConfig) AuthService {
+	passwordHistory := c.PasswordHistory
+	if passwordHistory < 1 {
+		passwordHistory = DefaultPasswordHistory
+	}
+
 	return &service{
-		userRepo:       c.UserRepo,
-		sessionService: c.SessionService,
-		tokenProvider:  c.TokenProvider,
+		userRepo:        c.UserRepo,
+		userTokenRepo:   c.UserTokenRepo,
+		sessionService:  c.SessionService,
+		mfaService:      c.MFAService,
+		lockoutService:  c.LockoutService,
+		tokenProvider:   c.TokenProvider,
+		mailer:          c.Mailer,
+		passwordHistory: passwordHistory,
 	}
 }
 
-func (s *service) Login(ctx context.Context, email string, password string) (*dto.LoginResponse, error) {
+func (s *service) Login(ctx context.Context, email, password string, device dto.DeviceInfo) (*dto.LoginResponse, error) {
 	logger := logging.FromContext(ctx)
 
 	logger.DebugContext(ctx, "login_attempt", "email", email)
@@ -47,6 +74,10 @@ func (s *service) Login(ctx context.Context, email string, password string) (*dt
 		)
 	}
 
+	if err := s.lockoutService.Check(ctx, email, device.IPAddress); err != nil {
+		return nil, err
+	}
+
 	enrichedUser, err := s.userRepo.GetEnrichedByEmail(ctx, email)
 	if err != nil {
 		logger.ErrorContext(
@@ -64,12 +95,19 @@ func (s *service) Login(ctx context.Context, email string, password string) (*dt
 
 	if enrichedUser == nil {
 		logger.DebugContext(ctx, "user_not_found", "email", email)
+		s.registerLoginFailure(ctx, email, device.IPAddress)
 		return nil, fault.NewUnauthorized("invalid credentials")
 	}
 
-	err = ValidateUser(email, password, enrichedUser.HashedPassword, enrichedUser.DeletedAt)
+	err = ValidateUser(email, password, enrichedUser.HashedPassword, enrichedUser.DeletedAt, enrichedUser.VerifiedAt)
 	if err != nil {
 		logger.DebugContext(ctx, "failed_validate_user", "email", email, "error", err)
+		switch fault.GetTag(err) {
+		case fault.UNVERIFIED_USER:
+			return nil, err
+		case fault.UNAUTHORIZED:
+			s.registerLoginFailure(ctx, email, device.IPAddress)
+		}
 		return nil, fault.New(
 			"failed to validate user",
 			fault.WithTag(fault.UNAUTHORIZED),
@@ -78,21 +116,138 @@ func (s *service) Login(ctx context.Context, email string, password string) (*dt
 		)
 	}
 
-	err = s.sessionService.DeactivateAllSessions(ctx, enrichedUser.ID)
+	mfaEnabled, err := s.mfaService.IsEnabled(ctx, enrichedUser.ID)
+	if err != nil {
+		logger.ErrorContext(ctx, "mfa_lookup_failed", "user_id", enrichedUser.ID, "error", err)
+		return nil, fault.NewInternalServerError("failed to login")
+	}
+
+	if mfaEnabled {
+		mfaToken, mfaClaims, err := s.tokenProvider.GenerateMFAPendingToken(enrichedUser)
+		if err != nil {
+			logger.ErrorContext(ctx, "mfa_token_generation_failed", "error", err)
+			return nil, fault.NewInternalServerError("failed to login")
+		}
+
+		// Tracked apart from access token revocation, which may not keep
+		// per token state, so VerifyMFA can consume it exactly once.
+		record, err := usertoken.NewWithSecret(enrichedUser.ID, usertoken.PurposeMFAPending, mfaClaims.ID, jwt.MFAPendingTokenDuration)
+		if err != nil {
+			logger.ErrorContext(ctx, "mfa_token_generation_failed", "error", err)
+			return nil, fault.NewInternalServerError("failed to login")
+		}
+		if err := s.userTokenRepo.Create(ctx, record); err != nil {
+			logger.ErrorContext(
+				ctx, "db_error",
+				"operation", "userTokenRepo.Create",
+				"user_id", enrichedUser.ID,
+				"error", err,
+			)
+			return nil, fault.NewInternalServerError("failed to login")
+		}
+
+		logger.InfoContext(ctx, "login_mfa_required", "user_id", enrichedUser.ID)
+
+		return &dto.LoginResponse{
+			MFARequired: true,
+			MFAToken:    mfaToken,
+		}, nil
+	}
+
+	return s.startSession(ctx, enrichedUser, device)
+}
+
+// VerifyMFA completes a login that was paused by Login because the account
+// has 2FA enabled.
+func (s *service) VerifyMFA(ctx context.Context, input dto.MFAVerifyRequest, device dto.DeviceInfo) (*dto.LoginResponse, error) {
+	logger := logging.FromContext(ctx)
+
+	claims, err := s.tokenProvider.VerifyMFAPendingToken(input.MFAToken)
+	if err != nil {
+		logger.DebugContext(ctx, "invalid_mfa_token", "error", err)
+		return nil, fault.NewUnauthorized("invalid or expired mfa token")
+	}
+
+	record, err := s.userTokenRepo.GetByHash(ctx, usertoken.PurposeMFAPending, crypto.HashToken(claims.ID))
+	if err != nil {
+		logger.ErrorContext(
+			ctx, "db_error",
+			"operation", "userTokenRepo.GetByHash",
+			"error", err,
+		)
+		return nil, fault.NewInternalServerError("failed to login")
+	}
+	if record == nil || !record.IsUsable() || record.UserID != claims.Subject {
+		logger.DebugContext(ctx, "mfa_token_reused", "user_id", claims.Subject, "found", record != nil)
+		return nil, fault.NewUnauthorized("invalid or expired mfa token")
+	}
+
+	enrichedUser, err := s.userRepo.GetEnrichedByID(ctx, claims.Subject)
 	if err != nil {
+		logger.ErrorContext(
+			ctx, "db_error",
+			"operation", "userRepo.GetEnrichedByID",
+			"error", err,
+		)
+		return nil, fault.NewInternalServerError("failed to login")
+	}
+
+	if enrichedUser == nil || enrichedUser.DeletedAt != nil {
 		return nil, fault.New(
-			"failed to deactivate user sessions",
-			fault.WithTag(fault.INTERNAL_SERVER_ERROR),
-			fault.WithHTTPCode(http.StatusInternalServerError),
-			fault.WithError(err),
+			"user must be active to login",
+			fault.WithHTTPCode(http.StatusUnauthorized),
+			fault.WithTag(fault.DISABLED_USER),
 		)
 	}
 
-	accessToken, _, err := s.tokenProvider.GenerateAccessToken(enrichedUser)
+	if err := s.lockoutService.Check(ctx, enrichedUser.Email, device.IPAddress); err != nil {
+		return nil, err
+	}
+
+	if err := s.mfaService.Verify(ctx, enrichedUser.ID, input.Code); err != nil {
+		if fault.GetTag(err) == fault.UNAUTHORIZED {
+			s.registerLoginFailure(ctx, enrichedUser.Email, device.IPAddress)
+		}
+		return nil, err
+	}
+
+	// The pending token has done its job and must not open a second session.
+	// Of concurrent requests with the same token only one consumes it.
+	ok, err := s.userTokenRepo.Consume(ctx, record.ID)
 	if err != nil {
-		logger.ErrorContext(ctx, "access_token_generation_failed", "error", err)
+		logger.ErrorContext(
+			ctx, "db_error",
+			"operation", "userTokenRepo.Consume",
+			"token_id", record.ID,
+			"error", err,
+		)
 		return nil, fault.NewInternalServerError("failed to login")
 	}
+	if !ok {
+		logger.DebugContext(ctx, "mfa_token_reused", "user_id", enrichedUser.ID)
+		return nil, fault.NewUnauthorized("invalid or expired mfa token")
+	}
+
+	return s.startSession(ctx, enrichedUser, device)
+}
+
+// registerLoginFailure counts a failed attempt against the account and the
+// IP. A store failure must not turn into a different response for the
+// client, so it is only logged.
+func (s *service) registerLoginFailure(ctx context.Context, email, ip string) {
+	if err := s.lockoutService.RegisterFailure(ctx, email, ip); err != nil {
+		logging.FromContext(ctx).ErrorContext(ctx, "lockout_register_failed", "email", email, "error", err)
+	}
+}
+
+// startSession opens a new session for the user and issues its token pair.
+func (s *service) startSession(
+	ctx context.Context,
+	enrichedUser *dto.EnrichedUserResponse,
+	device dto.DeviceInfo,
+) (*dto.LoginResponse, error) {
+	logger := logging.FromContext(ctx)
+
 	refreshToken, refreshTokenClaims, err := s.tokenProvider.GenerateRefreshToken(enrichedUser)
 	if err != nil {
 		logger.ErrorContext(ctx, "refresh_token_generation_failed", "error", err)
@@ -100,17 +255,28 @@ func (s *service) Login(ctx context.Context, email string, password string) (*dt
 	}
 
 	session, err := s.sessionService.CreateSession(
-		ctx, dto.CreateSession{UserID: enrichedUser.ID, JTI: refreshTokenClaims.ID},
+		ctx, dto.CreateSession{UserID: enrichedUser.ID, JTI: refreshTokenClaims.ID, Device: device},
 	)
 	if err != nil {
 		logger.ErrorContext(ctx, "session_generation_failed", "error", err)
 		return nil, fault.NewInternalServerError("failed to login")
 	}
 
+	accessToken, _, err := s.tokenProvider.GenerateAccessToken(enrichedUser, session.ID)
+	if err != nil {
+		logger.ErrorContext(ctx, "access_token_generation_failed", "error", err)
+		return nil, fault.NewInternalServerError("failed to login")
+	}
+
+	if err := s.lockoutService.RegisterSuccess(ctx, enrichedUser.Email); err != nil {
+		logger.ErrorContext(ctx, "lockout_reset_failed", "user_id", enrichedUser.ID, "error", err)
+	}
+
 	logger.InfoContext(
 		ctx, "login_successful",
 		"user_id", enrichedUser.ID,
 		"session_id", session.ID,
+		"device_label", device.Label,
 	)
 
 	return &dto.LoginResponse{
@@ -123,33 +289,33 @@ func (s *service) Login(ctx context.Context, email string, password string) (*dt
 func (s *service) Logout(ctx context.Context) error {
 	logger := logging.FromContext(ctx)
 
-	c, ok := ctx.Value(middlewares.AuthKey{}).(*jwt.Claims)
-	if !ok {
-		logger.ErrorContext(ctx, "missing_auth_context")
-		return fault.NewUnauthorized("access token not provided")
+	c, err := claimsFromContext(ctx)
+	if err != nil {
+		return err
 	}
 
 	logger.DebugContext(
 		ctx, "logout_attempt",
-		"user_id", c.User.ID,
-		"jti", c.ID,
+		"user_id", c.Subject,
+		"session_id", c.SessionID,
 	)
 
-	activeSession, err := s.sessionService.GetActiveSessionByUserID(ctx, c.User.ID)
+	activeSession, err := s.sessionService.GetSessionByID(ctx, c.SessionID)
 	if err != nil {
 		logger.ErrorContext(
 			ctx, "sessionServiceError",
-			"operation", "GetActiveSessionByUserID",
-			"user_id", c.User.ID,
+			"operation", "GetSessionByID",
+			"session_id", c.SessionID,
 			"error", err.Error(),
 		)
 		return fault.NewBadRequest("failed to retrieve active session")
 	}
 
-	if activeSession == nil {
+	if activeSession == nil || !activeSession.Active || activeSession.UserID != c.Subject {
 		logger.WarnContext(
 			ctx, "no_active_session",
-			"user_id", c.User.ID,
+			"user_id", c.Subject,
+			"session_id", c.SessionID,
 		)
 		return fault.NewNotFound("active session not found")
 	}
@@ -168,61 +334,285 @@ func (s *service) Logout(ctx context.Context) error {
 
 	logger.InfoContext(
 		ctx, "logout_success",
-		"user_id", c.User.ID,
+		"user_id", c.Subject,
 		"session_id", sess.ID,
 	)
 	return nil
 }
 
-// func (s *authService) RenewAccessToken(ctx context.Context, refreshToken string) (*dto.RenewTokenResponse, error) {
-// 	logger := logging.FromContext(ctx)
-// 	logger.DebugContext(ctx, "token_renewal_attempt",
-//
-// 		"token_prefix", "refresh_"+refreshToken[:6])
-//
-// 	claims, err := token.Verify(s.RefreshKey, refreshToken)
-// 	if err != nil {
-// 		logger.ErrorContext(ctx, "invalid_refresh_token",
-// 			"error", err,
-// 		)
-// 		return nil, fault.NewUnauthorized("invalid refresh token")
-// 	}
-//
-// 	sessionRecord, err := s.sessionRepo.GetByJTI(ctx, claims.ID)
-//
-// 	if err != nil || sessionRecord == nil || !sessionRecord.Active {
-// 		logger.WarnContext(ctx, "invalid_session",
-// 			"jti", claims.ID,
-// 			"active", sessionRecord != nil && sessionRecord.Active,
-// 			"error", err,
-// 		)
-// 		return nil, fault.NewBadRequest("invalid or inactive session")
-// 	}
-//
-// 	if sessionRecord.ExpiresAt.Before(time.Now()) {
-// 		logger.WarnContext(ctx, "expired_session",
-// 			"session_id", sessionRecord.ID,
-// 			"expires_at", sessionRecord.ExpiresAt,
-// 		)
-// 		return nil, fault.NewUnauthorized("session expired")
-// 	}
-//
-// 	accessToken, _, err := token.GenerateToken(s.AccessKey, claims.User, AccessTokenDuration)
-// 	if err != nil {
-// 		logger.ErrorContext(ctx, "access_token_generation_failed",
-// 			"user_id", claims.User.ID,
-// 			"error", err,
-// 		)
-// 		return nil, fault.NewInternalServerError("failed to generate access token")
-// 	}
-//
-// 	logger.InfoContext(ctx, "token_renewed",
-//
-// 		"user_id", claims.User.ID,
-// 		"session_id", sessionRecord.ID,
-// 	)
-//
-// 	return &dto.RenewTokenResponse{
-// 		AccessToken: accessToken,
-// 	}, nil
-// }
+func (s *service) ListSessions(ctx context.Context) ([]*dto.SessionResponse, error) {
+	logger := logging.FromContext(ctx)
+
+	c, err := claimsFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions, err := s.sessionService.GetActiveSessionsByUserID(ctx, c.Subject)
+	if err != nil {
+		logger.ErrorContext(
+			ctx, "sessionServiceError",
+			"operation", "GetActiveSessionsByUserID",
+			"user_id", c.Subject,
+			"error", err.Error(),
+		)
+		return nil, fault.NewInternalServerError("failed to retrieve sessions")
+	}
+
+	res := make([]*dto.SessionResponse, 0, len(sessions))
+	for _, sess := range sessions {
+		res = append(res, sess.ToResponse(sess.ID == c.SessionID))
+	}
+
+	return res, nil
+}
+
+func (s *service) RevokeSession(ctx context.Context, sessionID string) error {
+	logger := logging.FromContext(ctx)
+
+	c, err := claimsFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	sess, err := s.sessionService.GetSessionByID(ctx, sessionID)
+	if err != nil {
+		logger.ErrorContext(
+			ctx, "sessionServiceError",
+			"operation", "GetSessionByID",
+			"session_id", sessionID,
+			"error", err.Error(),
+		)
+		return fault.NewInternalServerError("failed to retrieve session")
+	}
+
+	// Sessions owned by someone else are reported as missing so their IDs
+	// cannot be probed.
+	if sess == nil || sess.UserID != c.Subject || !sess.Active {
+		return fault.NewNotFound("session not found")
+	}
+
+	sess.Deactivate()
+
+	_, err = s.sessionService.UpdateSession(ctx, sess)
+	if err != nil {
+		logger.ErrorContext(
+			ctx, "sessionServiceError",
+			"operation", "UpdateSession",
+			"session_id", sessionID,
+			"error", err.Error(),
+		)
+		return fault.NewInternalServerError("failed to revoke session")
+	}
+
+	logger.InfoContext(
+		ctx, "session_revoked",
+		"user_id", c.Subject,
+		"session_id", sessionID,
+		"current", sessionID == c.SessionID,
+	)
+
+	return nil
+}
+
+func (s *service) RevokeOtherSessions(ctx context.Context) (*dto.RevokeSessionsResponse, error) {
+	logger := logging.FromContext(ctx)
+
+	c, err := claimsFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	revoked, err := s.sessionService.RevokeOtherSessions(ctx, c.Subject, c.SessionID)
+	if err != nil {
+		logger.ErrorContext(
+			ctx, "sessionServiceError",
+			"operation", "RevokeOtherSessions",
+			"user_id", c.Subject,
+			"revoked", revoked,
+			"error", err.Error(),
+		)
+		return nil, fault.NewInternalServerError("failed to revoke sessions")
+	}
+
+	logger.InfoContext(
+		ctx, "other_sessions_revoked",
+		"user_id", c.Subject,
+		"session_id", c.SessionID,
+		"revoked", revoked,
+	)
+
+	return &dto.RevokeSessionsResponse{Revoked: revoked}, nil
+}
+
+func (s *service) RenewAccessToken(
+	ctx context.Context,
+	refreshToken string,
+	device dto.DeviceInfo,
+) (*dto.RenewTokenResponse, error) {
+	logger := logging.FromContext(ctx)
+
+	claims, err := s.tokenProvider.VerifyRefreshToken(refreshToken)
+	if err != nil {
+		logger.DebugContext(ctx, "invalid_refresh_token", "error", err)
+		return nil, fault.NewUnauthorized("invalid refresh token")
+	}
+
+	logger.DebugContext(
+		ctx, "token_renewal_attempt",
+		"user_id", claims.Subject,
+		"jti", claims.ID,
+	)
+
+	activeSession, err := s.sessionService.GetSessionByJTI(ctx, claims.ID)
+	if err != nil {
+		logger.ErrorContext(
+			ctx, "sessionServiceError",
+			"operation", "GetSessionByJTI",
+			"jti", claims.ID,
+			"error", err.Error(),
+		)
+		return nil, fault.NewInternalServerError("failed to renew token")
+	}
+
+	if activeSession == nil {
+		return nil, s.handleRefreshTokenReuse(ctx, claims)
+	}
+
+	if !activeSession.Active || activeSession.UserID != claims.Subject {
+		logger.WarnContext(
+			ctx, "invalid_session",
+			"session_id", activeSession.ID,
+			"active", activeSession.Active,
+		)
+		return nil, fault.NewUnauthorized("invalid or inactive session")
+	}
+
+	if activeSession.IsExpired() {
+		logger.WarnContext(
+			ctx, "expired_session",
+			"session_id", activeSession.ID,
+			"expires_at", activeSession.ExpiresAt,
+		)
+		return nil, fault.NewUnauthorized("session expired")
+	}
+
+	enrichedUser, err := s.userRepo.GetEnrichedByID(ctx, activeSession.UserID)
+	if err != nil {
+		logger.ErrorContext(
+			ctx, "db_error",
+			"operation", "userRepo.GetEnrichedByID",
+			"error", err,
+		)
+		return nil, fault.NewInternalServerError("failed to renew token")
+	}
+
+	if enrichedUser == nil || enrichedUser.DeletedAt != nil {
+		return nil, fault.New(
+			"user must be active to renew token",
+			fault.WithHTTPCode(http.StatusUnauthorized),
+			fault.WithTag(fault.DISABLED_USER),
+		)
+	}
+
+	accessToken, _, err := s.tokenProvider.GenerateAccessToken(enrichedUser, activeSession.ID)
+	if err != nil {
+		logger.ErrorContext(ctx, "access_token_generation_failed", "error", err)
+		return nil, fault.NewInternalServerError("failed to renew token")
+	}
+	newRefreshToken, refreshTokenClaims, err := s.tokenProvider.GenerateRefreshToken(enrichedUser)
+	if err != nil {
+		logger.ErrorContext(ctx, "refresh_token_generation_failed", "error", err)
+		return nil, fault.NewInternalServerError("failed to renew token")
+	}
+
+	activeSession.Touch(device.UserAgent, device.IPAddress)
+
+	_, err = s.sessionService.RotateSession(ctx, activeSession, refreshTokenClaims.ID)
+	if err != nil {
+		if fault.GetTag(err) != fault.CONFLICT {
+			return nil, err
+		}
+		logger.WarnContext(
+			ctx, "session_rotation_failed",
+			"session_id", activeSession.ID,
+			"error", err,
+		)
+		return nil, fault.NewUnauthorized("invalid or inactive session")
+	}
+
+	logger.InfoContext(
+		ctx, "token_renewed",
+		"user_id", enrichedUser.ID,
+		"session_id", activeSession.ID,
+	)
+
+	return &dto.RenewTokenResponse{
+		SessionID:    activeSession.ID,
+		AccessToken:  accessToken,
+		RefreshToken: newRefreshToken,
+	}, nil
+}
+
+// handleRefreshTokenReuse is called when a validly signed refresh token no
+// longer matches any session. If the token was already rotated, someone is
+// replaying it, so the whole token family is revoked.
+func (s *service) handleRefreshTokenReuse(ctx context.Context, claims *jwt.Claims) error {
+	logger := logging.FromContext(ctx)
+
+	rotatedSession, err := s.sessionService.GetSessionByRotatedJTI(ctx, claims.ID)
+	if err != nil {
+		logger.ErrorContext(
+			ctx, "sessionServiceError",
+			"operation", "GetSessionByRotatedJTI",
+			"jti", claims.ID,
+			"error", err.Error(),
+		)
+		return fault.NewInternalServerError("failed to renew token")
+	}
+
+	if rotatedSession == nil {
+		logger.WarnContext(ctx, "session_not_found", "jti", claims.ID)
+		return fault.NewUnauthorized("invalid or inactive session")
+	}
+
+	logger.WarnContext(
+		ctx, "security_event",
+		"event", "refresh_token_reuse_detected",
+		"user_id", rotatedSession.UserID,
+		"session_id", rotatedSession.ID,
+		"jti", claims.ID,
+	)
+
+	err = s.sessionService.RevokeSessionFamily(ctx, rotatedSession)
+	if err != nil {
+		logger.ErrorContext(
+			ctx, "sessionServiceError",
+			"operation", "RevokeSessionFamily",
+			"session_id", rotatedSession.ID,
+			"error", err.Error(),
+		)
+		return fault.NewInternalServerError("failed to renew token")
+	}
+
+	return fault.NewUnauthorized("refresh token reuse detected")
+}
+
+// claimsFromContext returns the claims WithAuth stored in the context. Only
+// tokens bound to a session are accepted, since every caller acts on it.
+func claimsFromContext(ctx context.Context) (*jwt.Claims, error) {
+	logger := logging.FromContext(ctx)
+
+	c, ok := middlewares.ClaimsFromContext(ctx)
+	if !ok {
+		logger.ErrorContext(ctx, "missing_auth_context")
+		return nil, fault.NewUnauthorized("access token not provided")
+	}
+
+	if c.SessionID == "" {
+		logger.WarnContext(ctx, "token_without_session", "user_id", c.Subject)
+		return nil, fault.NewUnauthorized("access token is not bound to a session")
+	}
+
+	return c, nil
+}
@@ -18,7 +18,7 @@ func ValidateCredentials(email, password string) error {
 	return nil
 }
 
-func ValidateUser(email, password, hashedPassword string, userDeletedAt *time.Time) error {
+func ValidateUser(email, password, hashedPassword string, userDeletedAt, userVerifiedAt *time.Time) error {
 	if !crypto.PasswordMatches(password, hashedPassword) {
 		return fault.NewUnauthorized("invalid credentials")
 	}
@@ -31,5 +31,13 @@ func ValidateUser(email, password, hashedPassword string, userDeletedAt *time.Ti
 		)
 	}
 
+	if userVerifiedAt == nil {
+		return fault.New(
+			"email must be verified to login",
+			fault.WithHTTPCode(http.StatusForbidden),
+			fault.WithTag(fault.UNVERIFIED_USER),
+		)
+	}
+
 	return nil
 }
@@ -0,0 +1,410 @@
+package category
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"msn/internal/infra/logging"
+	"msn/pkg/common/dto"
+	"msn/pkg/common/fault"
+	"msn/pkg/utils/slug"
+
+	"github.com/lib/pq"
+)
+
+// maxSlugAttempts bounds the numbered suffixes tried for a generated slug.
+const maxSlugAttempts = 20
+
+func (s *categoryService) CreateCategory(ctx context.Context, input dto.CreateCategoryRequest) (*dto.Category, error) {
+	logger := logging.FromContext(ctx)
+
+	var parent *Category
+	if input.ParentID != nil {
+		p, err := s.getCategory(ctx, *input.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		if p.IsDeleted() {
+			return nil, fault.NewUnprocessableEntity("parent category is deleted")
+		}
+
+		// Professionals attach to leaves only, so a node they use cannot
+		// get children until they are moved elsewhere.
+		users, err := s.categoryRepo.CountUsers(ctx, p.ID())
+		if err != nil {
+			logger.ErrorContext(ctx, "db_error",
+				"operation", "categoryRepo.CountUsers",
+				"category_id", p.ID(),
+				"error", err,
+			)
+			return nil, fault.NewInternalServerError("failed to create category")
+		}
+		if users > 0 {
+			return nil, fault.NewConflict(fmt.Sprintf("parent category is used by %d professionals", users))
+		}
+		parent = p
+	}
+
+	slugValue := input.Slug
+	if slugValue == "" {
+		generated, err := s.uniqueSlug(ctx, slug.Make(input.Name))
+		if err != nil {
+			return nil, err
+		}
+		slugValue = generated
+	}
+
+	position, err := s.categoryRepo.NextPosition(ctx, input.ParentID)
+	if err != nil {
+		logger.ErrorContext(ctx, "db_error",
+			"operation", "categoryRepo.NextPosition",
+			"error", err,
+		)
+		return nil, fault.NewInternalServerError("failed to create category")
+	}
+
+	category, err := New(input.Name, slugValue, input.Icon, parent, position)
+	if err != nil {
+		return nil, fault.NewUnprocessableEntity(fault.Message(err))
+	}
+
+	if err := s.categoryRepo.Create(ctx, category); err != nil {
+		return nil, s.writeError(ctx, "categoryRepo.Create", category.ID(), err)
+	}
+
+	s.cache.Clear(ctx)
+
+	logger.InfoContext(ctx, "category_created",
+		"category_id", category.ID(),
+		"parent_id", input.ParentID,
+		"name", category.Name(),
+	)
+
+	return category.ToResponse(), nil
+}
+
+func (s *categoryService) UpdateCategory(ctx context.Context, categoryID string, input dto.UpdateCategoryRequest) (*dto.Category, error) {
+	logger := logging.FromContext(ctx)
+
+	category, err := s.getCategory(ctx, categoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	if input.Name != nil {
+		if err := category.Rename(*input.Name); err != nil {
+			return nil, fault.NewUnprocessableEntity(fault.Message(err))
+		}
+	}
+	if input.Slug != nil {
+		if err := category.ChangeSlug(*input.Slug); err != nil {
+			return nil, fault.NewUnprocessableEntity(fault.Message(err))
+		}
+	}
+	if input.Icon != nil {
+		if err := category.ChangeIcon(*input.Icon); err != nil {
+			return nil, fault.NewUnprocessableEntity(fault.Message(err))
+		}
+	}
+
+	if err := s.categoryRepo.Update(ctx, category); err != nil {
+		return nil, s.writeError(ctx, "categoryRepo.Update", category.ID(), err)
+	}
+
+	s.cache.Clear(ctx)
+
+	logger.InfoContext(ctx, "category_updated", "category_id", category.ID())
+
+	return category.ToResponse(), nil
+}
+
+func (s *categoryService) ReorderCategories(ctx context.Context, parentID *string, ids []string) error {
+	logger := logging.FromContext(ctx)
+
+	ok, err := s.categoryRepo.Reorder(ctx, parentID, ids)
+	if err != nil {
+		logger.ErrorContext(ctx, "db_error",
+			"operation", "categoryRepo.Reorder",
+			"error", err,
+		)
+		return fault.NewInternalServerError("failed to reorder categories")
+	}
+	if !ok {
+		return fault.NewNotFound("one or more categories were not found under this parent")
+	}
+
+	s.cache.Clear(ctx)
+
+	logger.InfoContext(ctx, "categories_reordered",
+		"parent_id", parentID,
+		"count", len(ids),
+	)
+
+	return nil
+}
+
+// DeleteCategory soft deletes a node without active children. Professionals
+// attached to it are moved to replacementID when given; otherwise the
+// deletion is refused while any of them remain.
+func (s *categoryService) DeleteCategory(ctx context.Context, categoryID string, replacementID *string) (*dto.Category, error) {
+	logger := logging.FromContext(ctx)
+
+	category, err := s.getCategory(ctx, categoryID)
+	if err != nil {
+		return nil, err
+	}
+	if category.IsDeleted() {
+		return category.ToResponse(), nil
+	}
+
+	children, err := s.categoryRepo.CountActiveChildren(ctx, categoryID)
+	if err != nil {
+		logger.ErrorContext(ctx, "db_error",
+			"operation", "categoryRepo.CountActiveChildren",
+			"category_id", categoryID,
+			"error", err,
+		)
+		return nil, fault.NewInternalServerError("failed to delete category")
+	}
+	if children > 0 {
+		return nil, fault.NewConflict(fmt.Sprintf("category still has %d child categories", children))
+	}
+
+	if replacementID != nil {
+		if *replacementID == categoryID {
+			return nil, fault.NewBadRequest("replacement must be another category")
+		}
+		if err := s.requireLeaf(ctx, *replacementID); err != nil {
+			return nil, err
+		}
+	}
+
+	category.Delete()
+	ok, err := s.categoryRepo.Delete(ctx, category, replacementID)
+	if err != nil {
+		logger.ErrorContext(ctx, "db_error",
+			"operation", "categoryRepo.Delete",
+			"category_id", categoryID,
+			"error", err,
+		)
+		return nil, fault.NewInternalServerError("failed to delete category")
+	}
+	if !ok {
+		users, err := s.categoryRepo.CountUsers(ctx, categoryID)
+		if err != nil {
+			return nil, fault.NewConflict("category is still used by professionals, choose a replacement")
+		}
+		return nil, fault.NewConflict(fmt.Sprintf("category is still used by %d professionals, choose a replacement", users))
+	}
+
+	s.cache.Clear(ctx)
+
+	logger.InfoContext(ctx, "category_deleted",
+		"category_id", categoryID,
+		"replacement_id", replacementID,
+	)
+
+	return category.ToResponse(), nil
+}
+
+func (s *categoryService) RestoreCategory(ctx context.Context, categoryID string) (*dto.Category, error) {
+	logger := logging.FromContext(ctx)
+
+	category, err := s.getCategory(ctx, categoryID)
+	if err != nil {
+		return nil, err
+	}
+	if !category.IsDeleted() {
+		return category.ToResponse(), nil
+	}
+
+	if parentID := category.ParentID(); parentID != nil {
+		parent, err := s.getCategory(ctx, *parentID)
+		if err != nil {
+			return nil, err
+		}
+		if parent.IsDeleted() {
+			return nil, fault.NewUnprocessableEntity("parent category is deleted")
+		}
+	}
+
+	category.Restore()
+	if err := s.categoryRepo.Update(ctx, category); err != nil {
+		return nil, s.writeError(ctx, "categoryRepo.Update", category.ID(), err)
+	}
+
+	s.cache.Clear(ctx)
+
+	logger.InfoContext(ctx, "category_restored", "category_id", category.ID())
+
+	return category.ToResponse(), nil
+}
+
+func (s *categoryService) GetTranslations(ctx context.Context, categoryID string) ([]*dto.CategoryTranslation, error) {
+	if _, err := s.getCategory(ctx, categoryID); err != nil {
+		return nil, err
+	}
+
+	translations, err := s.categoryRepo.GetTranslations(ctx, categoryID)
+	if err != nil {
+		logging.FromContext(ctx).ErrorContext(ctx, "db_error",
+			"operation", "categoryRepo.GetTranslations",
+			"category_id", categoryID,
+			"error", err,
+		)
+		return nil, fault.NewInternalServerError("failed to retrieve category translations")
+	}
+
+	res := make([]*dto.CategoryTranslation, 0, len(translations))
+	for _, t := range translations {
+		res = append(res, t.ToResponse())
+	}
+
+	return res, nil
+}
+
+// SetTranslation names the node in locale, which must be a supported locale
+// other than the default one; the default name is changed by renaming.
+func (s *categoryService) SetTranslation(ctx context.Context, categoryID, locale, name string) (*dto.CategoryTranslation, error) {
+	logger := logging.FromContext(ctx)
+
+	if _, err := s.getCategory(ctx, categoryID); err != nil {
+		return nil, err
+	}
+
+	translation, err := NewTranslation(categoryID, locale, name)
+	if err != nil {
+		return nil, fault.NewUnprocessableEntity(fault.Message(err))
+	}
+
+	if err := s.categoryRepo.SaveTranslation(ctx, translation); err != nil {
+		logger.ErrorContext(ctx, "db_error",
+			"operation", "categoryRepo.SaveTranslation",
+			"category_id", categoryID,
+			"locale", locale,
+			"error", err,
+		)
+		return nil, fault.NewInternalServerError("failed to save category translation")
+	}
+
+	s.cache.Clear(ctx)
+
+	logger.InfoContext(ctx, "category_translation_saved",
+		"category_id", categoryID,
+		"locale", locale,
+	)
+
+	return translation.ToResponse(), nil
+}
+
+func (s *categoryService) DeleteTranslation(ctx context.Context, categoryID, locale string) error {
+	logger := logging.FromContext(ctx)
+
+	ok, err := s.categoryRepo.DeleteTranslation(ctx, categoryID, locale)
+	if err != nil {
+		logger.ErrorContext(ctx, "db_error",
+			"operation", "categoryRepo.DeleteTranslation",
+			"category_id", categoryID,
+			"locale", locale,
+			"error", err,
+		)
+		return fault.NewInternalServerError("failed to delete category translation")
+	}
+	if !ok {
+		return fault.NewNotFound("category translation not found")
+	}
+
+	s.cache.Clear(ctx)
+
+	logger.InfoContext(ctx, "category_translation_deleted",
+		"category_id", categoryID,
+		"locale", locale,
+	)
+
+	return nil
+}
+
+// requireLeaf checks that professionals can be moved to the node: it must
+// exist, be active and have no active children.
+func (s *categoryService) requireLeaf(ctx context.Context, categoryID string) error {
+	c, err := s.getCategory(ctx, categoryID)
+	if err != nil {
+		return err
+	}
+	if c.IsDeleted() {
+		return fault.NewUnprocessableEntity("replacement category is deleted")
+	}
+
+	children, err := s.categoryRepo.CountActiveChildren(ctx, categoryID)
+	if err != nil {
+		return fault.NewInternalServerError("failed to retrieve category")
+	}
+	if children > 0 {
+		return fault.NewUnprocessableEntity("replacement category has child categories")
+	}
+
+	return nil
+}
+
+func (s *categoryService) getCategory(ctx context.Context, categoryID string) (*Category, error) {
+	category, err := s.categoryRepo.GetByID(ctx, categoryID)
+	if err != nil {
+		logging.FromContext(ctx).ErrorContext(ctx, "db_error",
+			"operation", "categoryRepo.GetByID",
+			"category_id", categoryID,
+			"error", err,
+		)
+		return nil, fault.NewInternalServerError("failed to retrieve category")
+	}
+	if category == nil {
+		return nil, fault.NewNotFound("category not found")
+	}
+
+	return category, nil
+}
+
+// uniqueSlug returns base, or base with the first free numbered suffix.
+func (s *categoryService) uniqueSlug(ctx context.Context, base string) (string, error) {
+	if base == "" {
+		return "", fault.NewUnprocessableEntity("category name must contain letters or digits")
+	}
+
+	candidate := base
+	for i := 2; i <= maxSlugAttempts; i++ {
+		exists, err := s.categoryRepo.SlugExists(ctx, candidate)
+		if err != nil {
+			logging.FromContext(ctx).ErrorContext(ctx, "db_error",
+				"operation", "categoryRepo.SlugExists",
+				"slug", candidate,
+				"error", err,
+			)
+			return "", fault.NewInternalServerError("failed to create category")
+		}
+		if !exists && !reservedSlugs[candidate] {
+			return candidate, nil
+		}
+		candidate = fmt.Sprintf("%s-%d", base, i)
+	}
+
+	return "", fault.NewConflict("slug already taken, choose one explicitly")
+}
+
+// writeError turns a failed insert or update into a conflict when it hit a
+// unique constraint, and into an internal error otherwise.
+func (s *categoryService) writeError(ctx context.Context, operation, categoryID string, err error) error {
+	logging.FromContext(ctx).ErrorContext(ctx, "db_error",
+		"operation", operation,
+		"category_id", categoryID,
+		"error", err,
+	)
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		if pqErr.Constraint == "categories_slug_key" {
+			return fault.NewConflict("slug already taken")
+		}
+		return fault.NewConflict("name already taken")
+	}
+
+	return fault.NewInternalServerError("failed to save category")
+}
@@ -0,0 +1,28 @@
+package category
+
+import "context"
+
+// noCache is used when the service is built without a cache.
+type noCache struct{}
+
+func (noCache) Get(context.Context, string) (any, bool) { return nil, false }
+func (noCache) Set(context.Context, string, any)        {}
+func (noCache) Clear(context.Context)                   {}
+
+// cached returns the value kept under key, or loads and keeps it. Errors are
+// not kept.
+func cached[T any](ctx context.Context, c Cache, key string, load func() (T, error)) (T, error) {
+	if v, ok := c.Get(ctx, key); ok {
+		if value, ok := v.(T); ok {
+			return value, nil
+		}
+	}
+
+	value, err := load()
+	if err != nil {
+		return value, err
+	}
+
+	c.Set(ctx, key, value)
+	return value, nil
+}
@@ -2,14 +2,28 @@ package category
 
 import (
 	"msn/internal/infra/database/models"
+	"msn/pkg/common/dto"
 	"msn/pkg/common/fault"
+	"msn/pkg/utils/slug"
 	"msn/pkg/utils/uid"
+	"strings"
 	"time"
 )
 
+// reservedSlugs clash with the static routes under /api/v1/categories.
+var reservedSlugs = map[string]bool{"tree": true, "stats": true}
+
+// Category is a node of the category tree. Root nodes are what the app shows
+// as categories; professionals attach to leaf nodes.
 type Category struct {
 	id         string
+	parentID   *string
 	name       string
+	slug       string
+	icon       string
+	path       string
+	depth      int
+	position   int
 	created_at time.Time
 	updated_at *time.Time
 	deleted_at *time.Time
@@ -18,22 +32,45 @@ type Category struct {
 func NewFromModel(m models.Category) *Category {
 	return &Category{
 		id:         m.ID,
+		parentID:   m.ParentID,
 		name:       m.Name,
+		slug:       m.Slug,
+		icon:       m.Icon,
+		path:       m.Path,
+		depth:      m.Depth,
+		position:   m.Position,
 		created_at: m.CreatedAt,
 		updated_at: m.UpdatedAt,
 		deleted_at: m.DeletedAt,
 	}
 }
 
-func New(name string) (*Category, error) {
+// New creates a node under parent, or a root node when parent is nil. The
+// slug is derived from the name when empty.
+func New(name, slugValue, icon string, parent *Category, position int) (*Category, error) {
+	if slugValue == "" {
+		slugValue = slug.Make(name)
+	}
+
 	newCategory := Category{
 		id:         uid.New("category"),
 		name:       name,
+		slug:       slugValue,
+		icon:       icon,
+		position:   position,
 		created_at: time.Now(),
 		updated_at: nil,
 		deleted_at: nil,
 	}
 
+	newCategory.path = "/" + newCategory.id + "/"
+	if parent != nil {
+		parentID := parent.id
+		newCategory.parentID = &parentID
+		newCategory.path = parent.path + newCategory.id + "/"
+		newCategory.depth = parent.depth + 1
+	}
+
 	if err := newCategory.validate(); err != nil {
 		return nil, fault.New(
 			"failed to create category entity",
@@ -48,20 +85,108 @@ func New(name string) (*Category, error) {
 func (c *Category) Model() models.Category {
 	return models.Category{
 		ID:        c.id,
+		ParentID:  c.parentID,
 		Name:      c.name,
+		Slug:      c.slug,
+		Icon:      c.icon,
+		Path:      c.path,
+		Depth:     c.depth,
+		Position:  c.position,
 		CreatedAt: c.created_at,
 		UpdatedAt: c.updated_at,
 		DeletedAt: c.deleted_at,
 	}
 }
 
+func (c *Category) ToResponse() *dto.Category {
+	return &dto.Category{
+		ID:        c.id,
+		ParentID:  c.parentID,
+		Name:      c.name,
+		Slug:      c.slug,
+		Icon:      c.icon,
+		DeletedAt: c.deleted_at,
+	}
+}
+
 func (c *Category) validate() error {
 	if c.name == "" {
 		return fault.New("category name is required")
 	}
+	if len(c.name) > 255 {
+		return fault.New("category name must be at most 255 characters")
+	}
+	if !slug.Valid(c.slug) || len(c.slug) > 255 {
+		return fault.New("category slug must have only lowercase letters, digits and hyphens")
+	}
+	if reservedSlugs[c.slug] {
+		return fault.New("category slug is reserved")
+	}
+	if c.IsRoot() && c.icon == "" {
+		return fault.New("category icon is required")
+	}
 	return nil
 }
 
+// Rename changes the name, keeping the old one if the new name is invalid.
+// The slug is left as is so existing links keep working.
+func (c *Category) Rename(name string) error {
+	previous := c.name
+	c.name = name
+
+	if err := c.validate(); err != nil {
+		c.name = previous
+		return err
+	}
+
+	c.touch()
+	return nil
+}
+
+// ChangeSlug changes the slug, keeping the old one if the new slug is invalid.
+func (c *Category) ChangeSlug(slugValue string) error {
+	previous := c.slug
+	c.slug = slugValue
+
+	if err := c.validate(); err != nil {
+		c.slug = previous
+		return err
+	}
+
+	c.touch()
+	return nil
+}
+
+// ChangeIcon changes the icon, keeping the old one if the new icon is invalid.
+func (c *Category) ChangeIcon(icon string) error {
+	previous := c.icon
+	c.icon = icon
+
+	if err := c.validate(); err != nil {
+		c.icon = previous
+		return err
+	}
+
+	c.touch()
+	return nil
+}
+
+func (c *Category) Delete() {
+	now := time.Now()
+	c.deleted_at = &now
+	c.updated_at = &now
+}
+
+func (c *Category) Restore() {
+	c.deleted_at = nil
+	c.touch()
+}
+
+func (c *Category) touch() {
+	now := time.Now()
+	c.updated_at = &now
+}
+
 func FromID(id string) *Category {
 	return &Category{id: id}
 }
@@ -70,10 +195,45 @@ func (c *Category) ID() string {
 	return c.id
 }
 
+func (c *Category) ParentID() *string {
+	return c.parentID
+}
+
+func (c *Category) IsRoot() bool {
+	return c.parentID == nil
+}
+
 func (c *Category) Name() string {
 	return c.name
 }
 
+func (c *Category) Slug() string {
+	return c.slug
+}
+
+func (c *Category) Icon() string {
+	return c.icon
+}
+
+// Path lists the ids from the root down to the node, as /root/.../id/.
+func (c *Category) Path() string {
+	return c.path
+}
+
+// AncestorIDs returns the ids in the path above the node, root first.
+func (c *Category) AncestorIDs() []string {
+	ids := strings.Split(strings.Trim(c.path, "/"), "/")
+	return ids[:len(ids)-1]
+}
+
+func (c *Category) Depth() int {
+	return c.depth
+}
+
+func (c *Category) Position() int {
+	return c.position
+}
+
 func (c *Category) CreatedAt() time.Time {
 	return c.created_at
 }
@@ -81,3 +241,7 @@ func (c *Category) CreatedAt() time.Time {
 func (c *Category) DeletedAt() *time.Time {
 	return c.deleted_at
 }
+
+func (c *Category) IsDeleted() bool {
+	return c.deleted_at != nil
+}
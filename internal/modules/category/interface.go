@@ -3,13 +3,76 @@ package category
 import (
 	"context"
 	"msn/pkg/common/dto"
+	"time"
 )
 
+// The read methods taking a locale return names translated to it, falling
+// back to the node's own name. Nodes read that way must not be saved.
 type Repository interface {
-	GetCategories(ctx context.Context) ([]*dto.Category, error)
-	GetCategoriesWithSubcategories(ctx context.Context) ([]*dto.Category, error)
+	// GetCategories returns the root nodes with the number of professionals
+	// attached anywhere below them, as of the last stats refresh.
+	GetCategories(ctx context.Context, locale string) ([]*dto.Category, error)
+	// GetCategoriesWithSubcategories returns the root nodes with their
+	// direct children, both with their professionals counted like in
+	// GetCategories.
+	GetCategoriesWithSubcategories(ctx context.Context, locale string) ([]*dto.Category, error)
+	// GetTree returns every active node, parents before their children.
+	GetTree(ctx context.Context, locale string) ([]*Category, error)
+	// GetSubtree returns the active nodes below the given one, parents before
+	// their children.
+	GetSubtree(ctx context.Context, category *Category, locale string) ([]*Category, error)
+	GetByIDs(ctx context.Context, categoryIDs []string, locale string) ([]*Category, error)
+	GetByID(ctx context.Context, categoryID string) (*Category, error)
+	GetBySlug(ctx context.Context, slug, locale string) (*Category, error)
+	SlugExists(ctx context.Context, slug string) (bool, error)
+	NextPosition(ctx context.Context, parentID *string) (int, error)
+	Create(ctx context.Context, category *Category) error
+	Update(ctx context.Context, category *Category) error
+	// Reorder sets the position of each child of parentID (or of each root
+	// node when nil) to its index in ids. It changes nothing and returns
+	// false if any of them does not exist or has another parent.
+	Reorder(ctx context.Context, parentID *string, ids []string) (bool, error)
+	CountActiveChildren(ctx context.Context, categoryID string) (int, error)
+	CountUsers(ctx context.Context, categoryID string) (int, error)
+	// Delete soft deletes the node after moving its users to replacementID,
+	// when given. It changes nothing and returns false if active users are
+	// still attached to it.
+	Delete(ctx context.Context, category *Category, replacementID *string) (bool, error)
+	// RefreshStats recomputes the professional counts and daily signups.
+	RefreshStats(ctx context.Context) error
+	// GetStats returns the counts of every active node, with the signups
+	// since the given day.
+	GetStats(ctx context.Context, since time.Time) ([]NodeStats, error)
+	// GetSignupTrend returns the signups of each root node by day, since the
+	// given day. Days without signups are left out.
+	GetSignupTrend(ctx context.Context, since time.Time) ([]DaySignups, error)
+	GetTranslations(ctx context.Context, categoryID string) ([]*Translation, error)
+	// SaveTranslation creates the translation or replaces its name.
+	SaveTranslation(ctx context.Context, translation *Translation) error
+	// DeleteTranslation returns false if there was no such translation.
+	DeleteTranslation(ctx context.Context, categoryID, locale string) (bool, error)
+}
+
+// Cache keeps the results of the public reads. Entries expire on their own
+// and Clear drops all of them after a write.
+type Cache interface {
+	Get(ctx context.Context, key string) (any, bool)
+	Set(ctx context.Context, key string, value any)
+	Clear(ctx context.Context)
 }
 
 type Service interface {
-	GetCategories(ctx context.Context, includeSubs bool) ([]*dto.Category, error)
+	GetCategories(ctx context.Context, includeSubs bool, locale string) ([]*dto.Category, error)
+	GetTree(ctx context.Context, locale string) ([]*dto.Category, error)
+	GetBySlug(ctx context.Context, slug, locale string) (*dto.Category, error)
+	GetStats(ctx context.Context, days int, locale string) (*dto.CategoryStats, error)
+	RefreshStats(ctx context.Context) error
+	CreateCategory(ctx context.Context, input dto.CreateCategoryRequest) (*dto.Category, error)
+	UpdateCategory(ctx context.Context, categoryID string, input dto.UpdateCategoryRequest) (*dto.Category, error)
+	ReorderCategories(ctx context.Context, parentID *string, ids []string) error
+	DeleteCategory(ctx context.Context, categoryID string, replacementID *string) (*dto.Category, error)
+	RestoreCategory(ctx context.Context, categoryID string) (*dto.Category, error)
+	GetTranslations(ctx context.Context, categoryID string) ([]*dto.CategoryTranslation, error)
+	SetTranslation(ctx context.Context, categoryID, locale, name string) (*dto.CategoryTranslation, error)
+	DeleteTranslation(ctx context.Context, categoryID, locale string) error
 }
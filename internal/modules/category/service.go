@@ -2,6 +2,7 @@ package category
 
 import (
 	"context"
+	"fmt"
 	"msn/internal/infra/logging"
 	"msn/pkg/common/dto"
 	"msn/pkg/common/fault"
@@ -9,29 +10,46 @@ import (
 
 type ServiceConfig struct {
 	CategoryRepo Repository
+	// Cache keeps the public reads until they expire or an admin changes
+	// the categories. Nothing is cached when nil.
+	Cache Cache
 }
 
 type categoryService struct {
 	categoryRepo Repository
+	cache        Cache
 }
 
 func NewService(c ServiceConfig) Service {
+	cache := c.Cache
+	if cache == nil {
+		cache = noCache{}
+	}
+
 	return &categoryService{
 		categoryRepo: c.CategoryRepo,
+		cache:        cache,
 	}
 }
 
-func (s *categoryService) GetCategories(ctx context.Context, includeSubs bool) ([]*dto.Category, error) {
+func (s *categoryService) GetCategories(ctx context.Context, includeSubs bool, locale string) ([]*dto.Category, error) {
+	return cached(ctx, s.cache, fmt.Sprintf("categories:%s:%t", locale, includeSubs), func() ([]*dto.Category, error) {
+		return s.getCategories(ctx, includeSubs, locale)
+	})
+}
+
+func (s *categoryService) getCategories(ctx context.Context, includeSubs bool, locale string) ([]*dto.Category, error) {
 	logger := logging.FromContext(ctx)
 
 	logger.DebugContext(
 		ctx,
 		"get_categories_attempt",
 		"includeSubs", includeSubs,
+		"locale", locale,
 	)
 
 	if includeSubs {
-		records, err := s.categoryRepo.GetCategoriesWithSubcategories(ctx)
+		records, err := s.categoryRepo.GetCategoriesWithSubcategories(ctx, locale)
 		if err != nil {
 			logger.ErrorContext(ctx, "db_error",
 				"operation", "categoriesRepo.GetWithSubs",
@@ -43,7 +61,7 @@ func (s *categoryService) GetCategories(ctx context.Context, includeSubs bool) (
 		return records, nil
 	}
 
-	records, err := s.categoryRepo.GetCategories(ctx)
+	records, err := s.categoryRepo.GetCategories(ctx, locale)
 	if err != nil {
 		logger.ErrorContext(ctx, "db_error",
 			"operation", "categoriesRepo.GetWithSubs",
@@ -0,0 +1,140 @@
+package category
+
+import (
+	"context"
+	"fmt"
+	"msn/internal/infra/logging"
+	"msn/pkg/common/dto"
+	"msn/pkg/common/fault"
+	"time"
+)
+
+const (
+	// DefaultStatsRefreshInterval is how often the stats views are
+	// refreshed when no interval is configured.
+	DefaultStatsRefreshInterval = 5 * time.Minute
+	// MaxStatsDays bounds the signup window of GetStats.
+	MaxStatsDays = 365
+)
+
+// NodeStats are the counts of a node and of every node below it, as of the
+// last refresh of the stats.
+type NodeStats struct {
+	CategoryID    string
+	Professionals int
+	Signups       int
+	RefreshedAt   *time.Time
+}
+
+// DaySignups counts the professionals that signed up under a root node on a
+// given day.
+type DaySignups struct {
+	CategoryID string
+	Day        time.Time
+	Signups    int
+}
+
+// GetStats returns the active category tree with the professionals under each
+// node and how many of them signed up in the last days, today included. Root
+// nodes also get their signups day by day.
+func (s *categoryService) GetStats(ctx context.Context, days int, locale string) (*dto.CategoryStats, error) {
+	if days < 1 || days > MaxStatsDays {
+		return nil, fault.NewBadRequest(fmt.Sprintf("days must be between 1 and %d", MaxStatsDays))
+	}
+
+	to := time.Now().UTC().Truncate(24 * time.Hour)
+	from := to.AddDate(0, 0, 1-days)
+
+	key := fmt.Sprintf("stats:%s:%s:%d", locale, to.Format(time.DateOnly), days)
+	return cached(ctx, s.cache, key, func() (*dto.CategoryStats, error) {
+		return s.getStats(ctx, from, to, days, locale)
+	})
+}
+
+func (s *categoryService) getStats(ctx context.Context, from, to time.Time, days int, locale string) (*dto.CategoryStats, error) {
+	logger := logging.FromContext(ctx)
+
+	nodes, err := s.categoryRepo.GetTree(ctx, locale)
+	if err != nil {
+		logger.ErrorContext(ctx, "db_error",
+			"operation", "categoryRepo.GetTree",
+			"error", err,
+		)
+		return nil, fault.NewInternalServerError("failed to retrieve category stats")
+	}
+
+	stats, err := s.categoryRepo.GetStats(ctx, from)
+	if err != nil {
+		logger.ErrorContext(ctx, "db_error",
+			"operation", "categoryRepo.GetStats",
+			"error", err,
+		)
+		return nil, fault.NewInternalServerError("failed to retrieve category stats")
+	}
+
+	trend, err := s.categoryRepo.GetSignupTrend(ctx, from)
+	if err != nil {
+		logger.ErrorContext(ctx, "db_error",
+			"operation", "categoryRepo.GetSignupTrend",
+			"error", err,
+		)
+		return nil, fault.NewInternalServerError("failed to retrieve category stats")
+	}
+
+	res := &dto.CategoryStats{
+		From:       from.Format(time.DateOnly),
+		To:         to.Format(time.DateOnly),
+		Categories: buildTree(nodes, nil),
+	}
+
+	byID := make(map[string]NodeStats, len(stats))
+	for _, st := range stats {
+		byID[st.CategoryID] = st
+		if st.RefreshedAt != nil && (res.RefreshedAt == nil || st.RefreshedAt.After(*res.RefreshedAt)) {
+			res.RefreshedAt = st.RefreshedAt
+		}
+	}
+
+	byDay := make(map[string]map[string]int)
+	for _, t := range trend {
+		if byDay[t.CategoryID] == nil {
+			byDay[t.CategoryID] = make(map[string]int)
+		}
+		byDay[t.CategoryID][t.Day.Format(time.DateOnly)] = t.Signups
+	}
+
+	var fill func(nodes []*dto.Category)
+	fill = func(nodes []*dto.Category) {
+		for _, n := range nodes {
+			st := byID[n.ID]
+			n.Users = &st.Professionals
+			n.Signups = &st.Signups
+			fill(n.Children)
+		}
+	}
+	fill(res.Categories)
+
+	for _, root := range res.Categories {
+		root.Trend = make([]dto.DaySignups, 0, days)
+		for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+			key := day.Format(time.DateOnly)
+			root.Trend = append(root.Trend, dto.DaySignups{Day: key, Signups: byDay[root.ID][key]})
+		}
+	}
+
+	return res, nil
+}
+
+// RefreshStats recomputes the counts served by GetStats and GetCategories.
+func (s *categoryService) RefreshStats(ctx context.Context) error {
+	if err := s.categoryRepo.RefreshStats(ctx); err != nil {
+		logging.FromContext(ctx).ErrorContext(ctx, "db_error",
+			"operation", "categoryRepo.RefreshStats",
+			"error", err,
+		)
+		return fault.NewInternalServerError("failed to refresh category stats")
+	}
+
+	s.cache.Clear(ctx)
+	return nil
+}
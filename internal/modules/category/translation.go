@@ -0,0 +1,92 @@
+package category
+
+import (
+	"msn/internal/infra/database/models"
+	"msn/pkg/common/dto"
+	"msn/pkg/common/fault"
+	"time"
+)
+
+// Translation is the name of a node in a locale other than the default one,
+// which is the node's own name.
+type Translation struct {
+	categoryID string
+	locale     string
+	name       string
+	created_at time.Time
+	updated_at *time.Time
+}
+
+func NewTranslationFromModel(m models.CategoryTranslation) *Translation {
+	return &Translation{
+		categoryID: m.CategoryID,
+		locale:     m.Locale,
+		name:       m.Name,
+		created_at: m.CreatedAt,
+		updated_at: m.UpdatedAt,
+	}
+}
+
+func NewTranslation(categoryID, locale, name string) (*Translation, error) {
+	now := time.Now()
+	t := Translation{
+		categoryID: categoryID,
+		locale:     locale,
+		name:       name,
+		created_at: now,
+		updated_at: &now,
+	}
+
+	if err := t.validate(); err != nil {
+		return nil, fault.New(
+			"failed to create category translation entity",
+			fault.WithTag(fault.INVALID_ENTITY),
+			fault.WithError(err),
+		)
+	}
+
+	return &t, nil
+}
+
+func (t *Translation) Model() models.CategoryTranslation {
+	return models.CategoryTranslation{
+		CategoryID: t.categoryID,
+		Locale:     t.locale,
+		Name:       t.name,
+		CreatedAt:  t.created_at,
+		UpdatedAt:  t.updated_at,
+	}
+}
+
+func (t *Translation) ToResponse() *dto.CategoryTranslation {
+	return &dto.CategoryTranslation{
+		Locale:    t.locale,
+		Name:      t.name,
+		UpdatedAt: t.updated_at,
+	}
+}
+
+func (t *Translation) validate() error {
+	if t.locale == "" {
+		return fault.New("translation locale is required")
+	}
+	if t.name == "" {
+		return fault.New("translation name is required")
+	}
+	if len(t.name) > 255 {
+		return fault.New("translation name must be at most 255 characters")
+	}
+	return nil
+}
+
+func (t *Translation) CategoryID() string {
+	return t.categoryID
+}
+
+func (t *Translation) Locale() string {
+	return t.locale
+}
+
+func (t *Translation) Name() string {
+	return t.name
+}
@@ -0,0 +1,114 @@
+package category
+
+import (
+	"context"
+	"msn/internal/infra/logging"
+	"msn/pkg/common/dto"
+	"msn/pkg/common/fault"
+)
+
+// GetTree returns the active root nodes with all their descendants nested,
+// named in the given locale.
+func (s *categoryService) GetTree(ctx context.Context, locale string) ([]*dto.Category, error) {
+	return cached(ctx, s.cache, "tree:"+locale, func() ([]*dto.Category, error) {
+		return s.getTree(ctx, locale)
+	})
+}
+
+func (s *categoryService) getTree(ctx context.Context, locale string) ([]*dto.Category, error) {
+	logger := logging.FromContext(ctx)
+
+	nodes, err := s.categoryRepo.GetTree(ctx, locale)
+	if err != nil {
+		logger.ErrorContext(ctx, "db_error",
+			"operation", "categoryRepo.GetTree",
+			"error", err,
+		)
+		return nil, fault.NewInternalServerError("failed to retrieve category tree")
+	}
+
+	return buildTree(nodes, nil), nil
+}
+
+// GetBySlug returns an active node with its descendants nested and its
+// ancestors, root first, all named in the given locale.
+func (s *categoryService) GetBySlug(ctx context.Context, slug, locale string) (*dto.Category, error) {
+	return cached(ctx, s.cache, "slug:"+locale+":"+slug, func() (*dto.Category, error) {
+		return s.getBySlug(ctx, slug, locale)
+	})
+}
+
+func (s *categoryService) getBySlug(ctx context.Context, slug, locale string) (*dto.Category, error) {
+	logger := logging.FromContext(ctx)
+
+	category, err := s.categoryRepo.GetBySlug(ctx, slug, locale)
+	if err != nil {
+		logger.ErrorContext(ctx, "db_error",
+			"operation", "categoryRepo.GetBySlug",
+			"slug", slug,
+			"error", err,
+		)
+		return nil, fault.NewInternalServerError("failed to retrieve category")
+	}
+	if category == nil || category.IsDeleted() {
+		return nil, fault.NewNotFound("category not found")
+	}
+
+	descendants, err := s.categoryRepo.GetSubtree(ctx, category, locale)
+	if err != nil {
+		logger.ErrorContext(ctx, "db_error",
+			"operation", "categoryRepo.GetSubtree",
+			"category_id", category.ID(),
+			"error", err,
+		)
+		return nil, fault.NewInternalServerError("failed to retrieve category")
+	}
+
+	res := category.ToResponse()
+	res.Children = buildTree(descendants, category)
+
+	if ids := category.AncestorIDs(); len(ids) > 0 {
+		ancestors, err := s.categoryRepo.GetByIDs(ctx, ids, locale)
+		if err != nil {
+			logger.ErrorContext(ctx, "db_error",
+				"operation", "categoryRepo.GetByIDs",
+				"category_id", category.ID(),
+				"error", err,
+			)
+			return nil, fault.NewInternalServerError("failed to retrieve category")
+		}
+		for _, a := range ancestors {
+			res.Ancestors = append(res.Ancestors, a.ToResponse())
+		}
+	}
+
+	return res, nil
+}
+
+// buildTree nests nodes, which must come parents first, under root, or
+// returns the top-level nodes when root is nil.
+func buildTree(nodes []*Category, root *Category) []*dto.Category {
+	byID := make(map[string]*dto.Category, len(nodes)+1)
+	top := []*dto.Category{}
+
+	rootID := ""
+	if root != nil {
+		rootID = root.ID()
+	}
+
+	for _, n := range nodes {
+		res := n.ToResponse()
+		byID[n.ID()] = res
+
+		parentID := n.ParentID()
+		switch {
+		case parentID == nil || *parentID == rootID:
+			top = append(top, res)
+		case byID[*parentID] != nil:
+			parent := byID[*parentID]
+			parent.Children = append(parent.Children, res)
+		}
+	}
+
+	return top
+}
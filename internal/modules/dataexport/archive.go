@@ -0,0 +1,54 @@
+package dataexport
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"io"
+	"msn/pkg/common/dto"
+)
+
+// archive is the content of an export. Reviews, bookings and messages join
+// it once those modules exist.
+type archive struct {
+	profile  *dto.EnrichedUserResponse
+	sessions []*dto.SessionResponse
+	// avatar is the stored profile picture, nil when there is none.
+	avatar     io.Reader
+	avatarName string
+}
+
+// write writes a as a ZIP with a JSON file for each kind of data and the
+// avatar as it was uploaded.
+func (a archive) write(w io.Writer) error {
+	zw := zip.NewWriter(w)
+
+	if err := writeJSON(zw, "profile.json", a.profile); err != nil {
+		return err
+	}
+	if err := writeJSON(zw, "sessions.json", a.sessions); err != nil {
+		return err
+	}
+
+	if a.avatar != nil {
+		f, err := zw.Create(a.avatarName)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(f, a.avatar); err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
+
+func writeJSON(zw *zip.Writer, name string, v any) error {
+	f, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,132 @@
+package dataexport
+
+import (
+	"msn/internal/infra/database/models"
+	"msn/pkg/common/dto"
+	"msn/pkg/common/fault"
+	"msn/pkg/utils/uid"
+	"time"
+)
+
+type Status string
+
+const (
+	StatusPending    Status = "pending"
+	StatusProcessing Status = "processing"
+	StatusReady      Status = "ready"
+	StatusFailed     Status = "failed"
+	StatusExpired    Status = "expired"
+)
+
+// MaxAttempts is how many times an export is built before it is given up.
+const MaxAttempts = 3
+
+// Export is a request of a user for a copy of their personal data. It is
+// built in the background and stays downloadable until ExpiresAt.
+type Export struct {
+	ID          string
+	UserID      string
+	Status      Status
+	ObjectName  *string
+	Attempts    int
+	StartedAt   *time.Time
+	CompletedAt *time.Time
+	ExpiresAt   *time.Time
+	NotifiedAt  *time.Time
+	CreatedAt   time.Time
+	UpdatedAt   *time.Time
+}
+
+func New(userID string) (*Export, error) {
+	if userID == "" {
+		return nil, fault.New("userID is required", fault.WithTag(fault.INVALID_ENTITY))
+	}
+
+	return &Export{
+		ID:        uid.New("dexp"),
+		UserID:    userID,
+		Status:    StatusPending,
+		CreatedAt: time.Now(),
+	}, nil
+}
+
+func NewFromModel(m models.DataExport) *Export {
+	return &Export{
+		ID:          m.ID,
+		UserID:      m.UserID,
+		Status:      Status(m.Status),
+		ObjectName:  m.ObjectName,
+		Attempts:    m.Attempts,
+		StartedAt:   m.StartedAt,
+		CompletedAt: m.CompletedAt,
+		ExpiresAt:   m.ExpiresAt,
+		NotifiedAt:  m.NotifiedAt,
+		CreatedAt:   m.CreatedAt,
+		UpdatedAt:   m.UpdatedAt,
+	}
+}
+
+func (e *Export) ToModel() models.DataExport {
+	return models.DataExport{
+		ID:          e.ID,
+		UserID:      e.UserID,
+		Status:      string(e.Status),
+		ObjectName:  e.ObjectName,
+		Attempts:    e.Attempts,
+		StartedAt:   e.StartedAt,
+		CompletedAt: e.CompletedAt,
+		ExpiresAt:   e.ExpiresAt,
+		NotifiedAt:  e.NotifiedAt,
+		CreatedAt:   e.CreatedAt,
+		UpdatedAt:   e.UpdatedAt,
+	}
+}
+
+// Complete marks the export as built and stored as objectName, downloadable
+// for ttl.
+func (e *Export) Complete(objectName string, ttl time.Duration) {
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+
+	e.Status = StatusReady
+	e.ObjectName = &objectName
+	e.CompletedAt = &now
+	e.ExpiresAt = &expiresAt
+	e.UpdatedAt = &now
+}
+
+// Fail puts the export back in the queue, unless it already used all of its
+// attempts or retry is false.
+func (e *Export) Fail(retry bool) {
+	now := time.Now()
+	e.Status = StatusFailed
+	if retry && e.Attempts < MaxAttempts {
+		e.Status = StatusPending
+	}
+	e.UpdatedAt = &now
+}
+
+// Notified records that the user was mailed the download link.
+func (e *Export) Notified() {
+	now := time.Now()
+	e.NotifiedAt = &now
+	e.UpdatedAt = &now
+}
+
+// Expire marks the file of a ready export as removed.
+func (e *Export) Expire() {
+	now := time.Now()
+	e.Status = StatusExpired
+	e.ObjectName = nil
+	e.UpdatedAt = &now
+}
+
+func (e *Export) ToResponse() *dto.DataExportResponse {
+	return &dto.DataExportResponse{
+		ID:          e.ID,
+		Status:      string(e.Status),
+		CreatedAt:   e.CreatedAt,
+		CompletedAt: e.CompletedAt,
+		ExpiresAt:   e.ExpiresAt,
+	}
+}
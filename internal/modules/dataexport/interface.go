@@ -0,0 +1,30 @@
+package dataexport
+
+import (
+	"context"
+	"msn/pkg/common/dto"
+	"time"
+)
+
+type Repository interface {
+	Create(ctx context.Context, export *Export) error
+	Update(ctx context.Context, export *Export) error
+	// ClaimPending moves up to limit pending exports, and those stuck in
+	// processing since before staleBefore, to processing and returns them.
+	// Stuck exports that used all of their attempts are failed instead.
+	// Concurrent callers never claim the same export.
+	ClaimPending(ctx context.Context, staleBefore time.Time, limit int) ([]*Export, error)
+	// ClaimUnnotified returns up to limit unexpired ready exports whose
+	// link was not mailed and that were not touched since staleBefore. They
+	// are touched so concurrent callers do not claim them again.
+	ClaimUnnotified(ctx context.Context, staleBefore time.Time, limit int) ([]*Export, error)
+	// GetExpired returns up to limit ready exports whose link expired
+	// before now.
+	GetExpired(ctx context.Context, now time.Time, limit int) ([]*Export, error)
+}
+
+type Service interface {
+	Request(ctx context.Context) (*dto.DataExportResponse, error)
+	ProcessPending(ctx context.Context) error
+	RemoveExpired(ctx context.Context) error
+}
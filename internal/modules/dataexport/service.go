@@ -0,0 +1,363 @@
+package dataexport
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"msn/internal/infra/http/middlewares"
+	"msn/internal/infra/logging"
+	"msn/internal/infra/mailer"
+	"msn/internal/infra/storage"
+	"msn/internal/modules/session"
+	"msn/internal/modules/user"
+	"msn/pkg/common/dto"
+	"msn/pkg/common/fault"
+	"path"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	// DefaultLinkTTL is how long the download link of an export works when
+	// none is configured.
+	DefaultLinkTTL = 48 * time.Hour
+	// MaxLinkTTL is the longest a presigned storage link can last.
+	MaxLinkTTL = 7 * 24 * time.Hour
+	// DefaultInterval is how often pending exports are looked for.
+	DefaultInterval = time.Minute
+)
+
+const (
+	exportBucket = "user-exports"
+	exportName   = "meus-dados.zip"
+	batchSize    = 10
+	// staleAfter is how long an export can stay in processing before it is
+	// assumed lost, for instance to a restart, and built again.
+	staleAfter = 15 * time.Minute
+)
+
+type ServiceConfig struct {
+	ExportRepo    Repository
+	UserRepo      user.UserRepository
+	SessionRepo   session.SessionRepository
+	StorageClient *storage.StorageClient
+	Mailer        mailer.Mailer
+	// LinkTTL is how long the download link works, DefaultLinkTTL when not
+	// positive.
+	LinkTTL time.Duration
+}
+
+type service struct {
+	exportRepo    Repository
+	userRepo      user.UserRepository
+	sessionRepo   session.SessionRepository
+	storageClient *storage.StorageClient
+	mailer        mailer.Mailer
+	linkTTL       time.Duration
+}
+
+func NewService(c ServiceConfig) Service {
+	linkTTL := c.LinkTTL
+	if linkTTL <= 0 {
+		linkTTL = DefaultLinkTTL
+	}
+
+	return &service{
+		exportRepo:    c.ExportRepo,
+		userRepo:      c.UserRepo,
+		sessionRepo:   c.SessionRepo,
+		storageClient: c.StorageClient,
+		mailer:        c.Mailer,
+		linkTTL:       linkTTL,
+	}
+}
+
+// Request queues an export of the personal data of the logged in user. The
+// user gets an email with the download link once it is built.
+func (s *service) Request(ctx context.Context) (*dto.DataExportResponse, error) {
+	logger := logging.FromContext(ctx)
+
+	c, ok := middlewares.ClaimsFromContext(ctx)
+	if !ok {
+		return nil, fault.NewUnauthorized("access token not provided")
+	}
+
+	u, err := s.userRepo.GetByID(ctx, c.Subject)
+	if err != nil {
+		logger.ErrorContext(ctx, "db_error",
+			"operation", "userRepo.GetByID",
+			"user_id", c.Subject,
+			"error", err,
+		)
+		return nil, fault.NewInternalServerError("failed to request data export")
+	}
+	if u == nil {
+		return nil, fault.NewNotFound("user not found")
+	}
+
+	export, err := New(u.ID())
+	if err != nil {
+		return nil, fault.NewUnprocessableEntity(err.Error())
+	}
+
+	if err := s.exportRepo.Create(ctx, export); err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return nil, fault.NewConflict("a data export is already in progress")
+		}
+
+		logger.ErrorContext(ctx, "db_error",
+			"operation", "exportRepo.Create",
+			"user_id", u.ID(),
+			"error", err,
+		)
+		return nil, fault.NewInternalServerError("failed to request data export")
+	}
+
+	logger.InfoContext(ctx, "security_event",
+		"event", "data_export_requested",
+		"user_id", u.ID(),
+		"export_id", export.ID,
+	)
+
+	return export.ToResponse(), nil
+}
+
+// ProcessPending builds the queued exports and mails the links that could not
+// be mailed before. It is meant to run periodically; an export that fails is
+// retried on a later run, up to MaxAttempts times.
+func (s *service) ProcessPending(ctx context.Context) error {
+	logger := logging.FromContext(ctx)
+
+	exports, err := s.exportRepo.ClaimPending(ctx, time.Now().Add(-staleAfter), batchSize)
+	if err != nil {
+		logger.ErrorContext(ctx, "db_error",
+			"operation", "exportRepo.ClaimPending",
+			"error", err,
+		)
+		return fault.NewInternalServerError("failed to claim data exports")
+	}
+
+	for _, export := range exports {
+		s.process(ctx, export)
+	}
+
+	unnotified, err := s.exportRepo.ClaimUnnotified(ctx, time.Now().Add(-staleAfter), batchSize)
+	if err != nil {
+		logger.ErrorContext(ctx, "db_error",
+			"operation", "exportRepo.ClaimUnnotified",
+			"error", err,
+		)
+		return fault.NewInternalServerError("failed to claim data exports")
+	}
+
+	for _, export := range unnotified {
+		u, err := s.userRepo.GetByID(ctx, export.UserID)
+		if err != nil {
+			logger.ErrorContext(ctx, "db_error",
+				"operation", "userRepo.GetByID",
+				"user_id", export.UserID,
+				"error", err,
+			)
+			continue
+		}
+		if u == nil {
+			// Deleted since; the file goes away once the link expires.
+			continue
+		}
+
+		s.notify(ctx, export, u.Email(), u.Name())
+	}
+
+	return nil
+}
+
+func (s *service) process(ctx context.Context, export *Export) {
+	logger := logging.FromContext(ctx)
+
+	profile, err := s.build(ctx, export)
+	switch {
+	case err != nil:
+		export.Fail(true)
+		logger.ErrorContext(ctx, "data_export_failed",
+			"export_id", export.ID,
+			"user_id", export.UserID,
+			"attempts", export.Attempts,
+			"status", export.Status,
+			"error", err,
+		)
+	case profile == nil:
+		// The account was deleted after the export was requested.
+		export.Fail(false)
+		logger.InfoContext(ctx, "data_export_user_gone",
+			"export_id", export.ID,
+			"user_id", export.UserID,
+		)
+	}
+
+	if err := s.exportRepo.Update(ctx, export); err != nil {
+		logger.ErrorContext(ctx, "db_error",
+			"operation", "exportRepo.Update",
+			"export_id", export.ID,
+			"error", err,
+		)
+		return
+	}
+
+	if export.Status != StatusReady {
+		return
+	}
+
+	s.notify(ctx, export, profile.Email, profile.Name)
+}
+
+// notify mails the download link of a ready export. When it fails the export
+// is left unnotified, so ProcessPending tries again later.
+func (s *service) notify(ctx context.Context, export *Export, email, name string) {
+	logger := logging.FromContext(ctx)
+
+	if err := s.sendReadyEmail(ctx, email, name, export); err != nil {
+		logger.ErrorContext(ctx, "mailer_error",
+			"export_id", export.ID,
+			"user_id", export.UserID,
+			"error", err,
+		)
+		return
+	}
+
+	export.Notified()
+	if err := s.exportRepo.Update(ctx, export); err != nil {
+		logger.ErrorContext(ctx, "db_error",
+			"operation", "exportRepo.Update",
+			"export_id", export.ID,
+			"error", err,
+		)
+	}
+
+	logger.InfoContext(ctx, "security_event",
+		"event", "data_export_ready",
+		"user_id", export.UserID,
+		"export_id", export.ID,
+	)
+}
+
+// build writes the archive of the export to storage and completes it. It
+// returns a nil profile when the user no longer exists.
+func (s *service) build(ctx context.Context, export *Export) (*dto.EnrichedUserResponse, error) {
+	profile, err := s.userRepo.GetEnrichedByID(ctx, export.UserID)
+	if err != nil || profile == nil {
+		return nil, err
+	}
+
+	sessions, err := s.sessionRepo.GetAllByUserID(ctx, export.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	a := archive{
+		profile:  profile,
+		sessions: make([]*dto.SessionResponse, len(sessions)),
+	}
+	for i, sess := range sessions {
+		a.sessions[i] = sess.ToResponse(false)
+	}
+
+	if bucket, objectName, ok := user.AvatarObject(profile.AvatarURL); ok {
+		avatar, err := s.storageClient.GetFile(bucket, objectName)
+		switch {
+		case errors.Is(err, storage.ErrObjectNotFound):
+			logging.FromContext(ctx).WarnContext(ctx, "data_export_avatar_missing",
+				"export_id", export.ID,
+				"object", objectName,
+			)
+		case err != nil:
+			return nil, err
+		default:
+			defer avatar.Close()
+			a.avatar = avatar
+			a.avatarName = "avatar" + path.Ext(objectName)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := a.write(&buf); err != nil {
+		return nil, err
+	}
+
+	objectName := fmt.Sprintf("export_%s_%s.zip", export.UserID, export.ID)
+	key, err := s.storageClient.Upload(exportBucket, objectName, &buf, int64(buf.Len()), "application/zip")
+	if err != nil {
+		return nil, err
+	}
+
+	export.Complete(key, s.linkTTL)
+	return profile, nil
+}
+
+// sendReadyEmail mails a link that lasts as long as the export is kept, which
+// is less than the configured TTL when the email is sent late.
+func (s *service) sendReadyEmail(ctx context.Context, email, name string, export *Export) error {
+	ttl := time.Until(*export.ExpiresAt)
+	if ttl <= 0 {
+		return errors.New("data export already expired")
+	}
+
+	link, err := s.storageClient.PresignedURL(exportBucket, *export.ObjectName, exportName, ttl)
+	if err != nil {
+		return err
+	}
+
+	msg, err := mailer.NewMessage(email, mailer.DefaultLocale, mailer.TemplateDataExportReady, mailer.DataExportReadyData{
+		Name:           name,
+		Link:           link,
+		ExpiresInHours: int(math.Ceil(ttl.Hours())),
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(ctx, msg)
+}
+
+// RemoveExpired deletes from storage the exports whose link expired, so the
+// personal data they hold does not outlive it.
+func (s *service) RemoveExpired(ctx context.Context) error {
+	logger := logging.FromContext(ctx)
+
+	exports, err := s.exportRepo.GetExpired(ctx, time.Now(), batchSize)
+	if err != nil {
+		logger.ErrorContext(ctx, "db_error",
+			"operation", "exportRepo.GetExpired",
+			"error", err,
+		)
+		return fault.NewInternalServerError("failed to retrieve expired data exports")
+	}
+
+	for _, export := range exports {
+		if export.ObjectName != nil {
+			if err := s.storageClient.RemoveFile(exportBucket, *export.ObjectName); err != nil {
+				// Left as ready so the next run tries again.
+				logger.ErrorContext(ctx, "data_export_removal_failed",
+					"export_id", export.ID,
+					"error", err,
+				)
+				continue
+			}
+		}
+
+		export.Expire()
+		if err := s.exportRepo.Update(ctx, export); err != nil {
+			logger.ErrorContext(ctx, "db_error",
+				"operation", "exportRepo.Update",
+				"export_id", export.ID,
+				"error", err,
+			)
+			return fault.NewInternalServerError("failed to expire data export")
+		}
+	}
+
+	return nil
+}
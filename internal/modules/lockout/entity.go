@@ -0,0 +1,53 @@
+package lockout
+
+import (
+	"msn/internal/infra/database/models"
+	"time"
+)
+
+// Attempts counts the failed logins seen for a key (an account or an IP)
+// inside the current window.
+type Attempts struct {
+	Key            string
+	Failures       int
+	FirstFailureAt time.Time
+	LastFailureAt  time.Time
+	LockedUntil    *time.Time
+}
+
+func NewFromModel(m models.LoginAttempt) *Attempts {
+	return &Attempts{
+		Key:            m.Key,
+		Failures:       m.Failures,
+		FirstFailureAt: m.FirstFailureAt,
+		LastFailureAt:  m.LastFailureAt,
+		LockedUntil:    m.LockedUntil,
+	}
+}
+
+func (a *Attempts) ToModel() models.LoginAttempt {
+	return models.LoginAttempt{
+		Key:            a.Key,
+		Failures:       a.Failures,
+		FirstFailureAt: a.FirstFailureAt,
+		LastFailureAt:  a.LastFailureAt,
+		LockedUntil:    a.LockedUntil,
+	}
+}
+
+func (a *Attempts) IsLocked(now time.Time) bool {
+	return a.LockedUntil != nil && a.LockedUntil.After(now)
+}
+
+// InWindow reports whether the failures still count at now.
+func (a *Attempts) InWindow(now time.Time, window time.Duration) bool {
+	return a.FirstFailureAt.Add(window).After(now)
+}
+
+func AccountKey(email string) string {
+	return "account:" + normalizeEmail(email)
+}
+
+func IPKey(ip string) string {
+	return "ip:" + ip
+}
@@ -0,0 +1,23 @@
+package lockout
+
+import (
+	"context"
+	"time"
+)
+
+// Store keeps failure counters. Implementations must make Increment atomic,
+// restarting the count when the previous window has elapsed.
+type Store interface {
+	Get(ctx context.Context, key string) (*Attempts, error)
+	Increment(ctx context.Context, key string, window time.Duration) (*Attempts, error)
+	Lock(ctx context.Context, key string, until time.Time) error
+	Reset(ctx context.Context, key string) error
+}
+
+type Service interface {
+	Check(ctx context.Context, email, ip string) error
+	RegisterFailure(ctx context.Context, email, ip string) error
+	RegisterSuccess(ctx context.Context, email string) error
+	Unlock(ctx context.Context, email string) error
+	UnlockIP(ctx context.Context, ip string) error
+}
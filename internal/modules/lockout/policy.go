@@ -0,0 +1,82 @@
+package lockout
+
+import (
+	"strings"
+	"time"
+)
+
+// Policy describes how failures on a key are throttled. The first
+// FreeAttempts failures cost nothing; every further failure doubles the
+// wait before the next attempt, up to MaxDelay. Reaching MaxFailures locks
+// the key for LockoutDuration.
+type Policy struct {
+	FreeAttempts    int
+	MaxFailures     int
+	BaseDelay       time.Duration
+	MaxDelay        time.Duration
+	Window          time.Duration
+	LockoutDuration time.Duration
+}
+
+var (
+	DefaultAccountPolicy = Policy{
+		FreeAttempts:    3,
+		MaxFailures:     10,
+		BaseDelay:       time.Second,
+		MaxDelay:        30 * time.Second,
+		Window:          15 * time.Minute,
+		LockoutDuration: 15 * time.Minute,
+	}
+	DefaultIPPolicy = Policy{
+		FreeAttempts:    10,
+		MaxFailures:     50,
+		BaseDelay:       time.Second,
+		MaxDelay:        30 * time.Second,
+		Window:          15 * time.Minute,
+		LockoutDuration: 15 * time.Minute,
+	}
+)
+
+// Delay returns how long a client must wait after its last failure.
+func (p Policy) Delay(failures int) time.Duration {
+	if failures <= p.FreeAttempts {
+		return 0
+	}
+
+	delay := p.BaseDelay
+	for i := p.FreeAttempts + 1; i < failures; i++ {
+		delay *= 2
+		if delay >= p.MaxDelay {
+			return p.MaxDelay
+		}
+	}
+
+	return delay
+}
+
+// RetryAfter returns how long a must wait before another attempt, or zero
+// when it can try right away.
+func (p Policy) RetryAfter(a *Attempts, now time.Time) time.Duration {
+	if a == nil {
+		return 0
+	}
+
+	if a.IsLocked(now) {
+		return a.LockedUntil.Sub(now)
+	}
+
+	if !a.InWindow(now, p.Window) {
+		return 0
+	}
+
+	next := a.LastFailureAt.Add(p.Delay(a.Failures))
+	if next.After(now) {
+		return next.Sub(now)
+	}
+
+	return 0
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
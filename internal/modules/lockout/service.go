@@ -0,0 +1,145 @@
+package lockout
+
+import (
+	"context"
+	"math"
+	"msn/internal/infra/logging"
+	"msn/pkg/common/fault"
+	"strconv"
+	"time"
+)
+
+type ServiceConfig struct {
+	Store         Store
+	AccountPolicy Policy
+	IPPolicy      Policy
+}
+
+type service struct {
+	store         Store
+	accountPolicy Policy
+	ipPolicy      Policy
+}
+
+func NewService(c ServiceConfig) Service {
+	return &service{
+		store:         c.Store,
+		accountPolicy: c.AccountPolicy,
+		ipPolicy:      c.IPPolicy,
+	}
+}
+
+// Check returns a TooManyRequests fault carrying Retry-After when either the
+// account or the IP has to wait before trying again.
+func (s *service) Check(ctx context.Context, email, ip string) error {
+	logger := logging.FromContext(ctx)
+	now := time.Now()
+
+	var retryAfter time.Duration
+	for _, target := range s.targets(email, ip) {
+		attempts, err := s.store.Get(ctx, target.key)
+		if err != nil {
+			logger.ErrorContext(ctx, "lockout_store_error",
+				"operation", "store.Get",
+				"key", target.key,
+				"error", err,
+			)
+			return fault.NewInternalServerError("failed to check login attempts")
+		}
+
+		if wait := target.policy.RetryAfter(attempts, now); wait > retryAfter {
+			retryAfter = wait
+		}
+	}
+
+	if retryAfter <= 0 {
+		return nil
+	}
+
+	logger.DebugContext(ctx, "login_throttled", "email", email, "ip", ip, "retry_after", retryAfter)
+
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	return fault.NewTooManyRequests(
+		"too many failed login attempts, try again later",
+		fault.WithHeader("Retry-After", strconv.Itoa(seconds)),
+	)
+}
+
+func (s *service) RegisterFailure(ctx context.Context, email, ip string) error {
+	logger := logging.FromContext(ctx)
+	now := time.Now()
+
+	for _, target := range s.targets(email, ip) {
+		attempts, err := s.store.Increment(ctx, target.key, target.policy.Window)
+		if err != nil {
+			logger.ErrorContext(ctx, "lockout_store_error",
+				"operation", "store.Increment",
+				"key", target.key,
+				"error", err,
+			)
+			return err
+		}
+
+		if attempts.Failures < target.policy.MaxFailures || attempts.IsLocked(now) {
+			continue
+		}
+
+		until := now.Add(target.policy.LockoutDuration)
+		if err := s.store.Lock(ctx, target.key, until); err != nil {
+			logger.ErrorContext(ctx, "lockout_store_error",
+				"operation", "store.Lock",
+				"key", target.key,
+				"error", err,
+			)
+			return err
+		}
+
+		logger.WarnContext(ctx, "security_event",
+			"event", "login_lockout",
+			"key", target.key,
+			"failures", attempts.Failures,
+			"locked_until", until,
+		)
+	}
+
+	return nil
+}
+
+// RegisterSuccess clears the account counter. The IP counter is left alone
+// so a valid login cannot be used to reset an ongoing spraying attempt.
+func (s *service) RegisterSuccess(ctx context.Context, email string) error {
+	return s.store.Reset(ctx, AccountKey(email))
+}
+
+func (s *service) Unlock(ctx context.Context, email string) error {
+	if err := s.store.Reset(ctx, AccountKey(email)); err != nil {
+		return err
+	}
+
+	logging.FromContext(ctx).InfoContext(ctx, "account_unlocked", "email", normalizeEmail(email))
+
+	return nil
+}
+
+func (s *service) UnlockIP(ctx context.Context, ip string) error {
+	if err := s.store.Reset(ctx, IPKey(ip)); err != nil {
+		return err
+	}
+
+	logging.FromContext(ctx).InfoContext(ctx, "ip_unlocked", "ip", ip)
+
+	return nil
+}
+
+type target struct {
+	key    string
+	policy Policy
+}
+
+func (s *service) targets(email, ip string) []target {
+	targets := []target{{key: AccountKey(email), policy: s.accountPolicy}}
+	if ip != "" {
+		targets = append(targets, target{key: IPKey(ip), policy: s.ipPolicy})
+	}
+	return targets
+}
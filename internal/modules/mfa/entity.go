@@ -0,0 +1,168 @@
+package mfa
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"msn/internal/infra/database/models"
+	"msn/pkg/common/fault"
+	"msn/pkg/utils/crypto"
+	"msn/pkg/utils/totp"
+	"msn/pkg/utils/uid"
+	"strings"
+	"time"
+)
+
+const (
+	recoveryCodeCount = 10
+	recoveryCodeSize  = 10
+	// allowedSkew accepts codes from the previous and next time step to
+	// tolerate clock drift on the user's device.
+	allowedSkew = 1
+)
+
+// Enrollment holds the TOTP secret of a user. It only protects the account
+// once EnabledAt is set, after the user proved they can generate codes.
+type Enrollment struct {
+	UserID       string
+	Secret       string
+	LastUsedStep int64
+	EnabledAt    *time.Time
+	CreatedAt    time.Time
+	UpdatedAt    *time.Time
+}
+
+func New(userID string) (*Enrollment, error) {
+	if userID == "" {
+		return nil, fault.New("userID is required", fault.WithTag(fault.INVALID_ENTITY))
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, fault.New(
+			"failed to generate totp secret",
+			fault.WithTag(fault.INVALID_ENTITY),
+			fault.WithError(err),
+		)
+	}
+
+	return &Enrollment{
+		UserID:       userID,
+		Secret:       secret,
+		LastUsedStep: 0,
+		EnabledAt:    nil,
+		CreatedAt:    time.Now(),
+		UpdatedAt:    nil,
+	}, nil
+}
+
+func NewFromModel(m models.UserMFA) *Enrollment {
+	return &Enrollment{
+		UserID:       m.UserID,
+		Secret:       m.Secret,
+		LastUsedStep: m.LastUsedStep,
+		EnabledAt:    m.EnabledAt,
+		CreatedAt:    m.CreatedAt,
+		UpdatedAt:    m.UpdatedAt,
+	}
+}
+
+func (e *Enrollment) ToModel() models.UserMFA {
+	return models.UserMFA{
+		UserID:       e.UserID,
+		Secret:       e.Secret,
+		LastUsedStep: e.LastUsedStep,
+		EnabledAt:    e.EnabledAt,
+		CreatedAt:    e.CreatedAt,
+		UpdatedAt:    e.UpdatedAt,
+	}
+}
+
+func (e *Enrollment) IsEnabled() bool {
+	return e.EnabledAt != nil
+}
+
+func (e *Enrollment) Enable() {
+	now := time.Now()
+	e.EnabledAt = &now
+	e.UpdatedAt = &now
+}
+
+// MatchCode returns the time step code belongs to. Codes from a step that
+// was already used are rejected, so a code cannot be replayed.
+func (e *Enrollment) MatchCode(code string) (int64, bool) {
+	step, ok := totp.Validate(e.Secret, code, time.Now(), allowedSkew)
+	if !ok || step <= e.LastUsedStep {
+		return 0, false
+	}
+	return step, true
+}
+
+func (e *Enrollment) UseStep(step int64) {
+	now := time.Now()
+	e.LastUsedStep = step
+	e.UpdatedAt = &now
+}
+
+type RecoveryCode struct {
+	ID        string
+	UserID    string
+	CodeHash  string
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+// NewRecoveryCodes generates a fresh set of recovery codes. The plain codes
+// are returned once so they can be shown to the user; only hashes are kept.
+func NewRecoveryCodes(userID string) ([]*RecoveryCode, []string, error) {
+	codes := make([]*RecoveryCode, 0, recoveryCodeCount)
+	plain := make([]string, 0, recoveryCodeCount)
+
+	for range recoveryCodeCount {
+		buf := make([]byte, recoveryCodeSize)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, fault.New(
+				"failed to generate recovery code",
+				fault.WithTag(fault.INVALID_ENTITY),
+				fault.WithError(err),
+			)
+		}
+
+		raw := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(buf)
+		formatted := strings.Join([]string{raw[0:4], raw[4:8], raw[8:12], raw[12:16]}, "-")
+
+		codes = append(codes, &RecoveryCode{
+			ID:        uid.New("mfarc"),
+			UserID:    userID,
+			CodeHash:  HashRecoveryCode(formatted),
+			UsedAt:    nil,
+			CreatedAt: time.Now(),
+		})
+		plain = append(plain, formatted)
+	}
+
+	return codes, plain, nil
+}
+
+func (c *RecoveryCode) ToModel() models.MFARecoveryCode {
+	return models.MFARecoveryCode{
+		ID:        c.ID,
+		UserID:    c.UserID,
+		CodeHash:  c.CodeHash,
+		UsedAt:    c.UsedAt,
+		CreatedAt: c.CreatedAt,
+	}
+}
+
+// HashRecoveryCode normalizes the code the way users tend to type it before
+// hashing, so case and separators do not matter.
+func HashRecoveryCode(code string) string {
+	normalized := strings.ToUpper(code)
+	normalized = strings.NewReplacer("-", "", " ", "").Replace(normalized)
+	return crypto.HashToken(normalized)
+}
+
+// IsRecoveryCode tells recovery codes apart from TOTP codes, which are
+// always numeric.
+func IsRecoveryCode(code string) bool {
+	return len(strings.TrimSpace(code)) > totp.Digits
+}
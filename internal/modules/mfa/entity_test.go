@@ -0,0 +1,47 @@
+package mfa
+
+import (
+	"msn/pkg/utils/totp"
+	"testing"
+	"time"
+)
+
+func TestMatchCodeRejectsReplay(t *testing.T) {
+	enrollment, err := New("user_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, err := totp.Code(enrollment.Secret, totp.Step(time.Now()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	step, ok := enrollment.MatchCode(code)
+	if !ok {
+		t.Fatal("MatchCode rejected a fresh code")
+	}
+	enrollment.UseStep(step)
+
+	if _, ok := enrollment.MatchCode(code); ok {
+		t.Error("MatchCode accepted a code that was already used")
+	}
+}
+
+func TestMatchCodeRejectsOlderStep(t *testing.T) {
+	enrollment, err := New("user_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	current := totp.Step(time.Now())
+	enrollment.UseStep(current)
+
+	previous, err := totp.Code(enrollment.Secret, current-1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := enrollment.MatchCode(previous); ok {
+		t.Error("MatchCode accepted a code older than the last used one")
+	}
+}
@@ -0,0 +1,23 @@
+package mfa
+
+import (
+	"context"
+	"msn/pkg/common/dto"
+)
+
+type Repository interface {
+	GetByUserID(ctx context.Context, userID string) (*Enrollment, error)
+	Save(ctx context.Context, enrollment *Enrollment) error
+	UseStep(ctx context.Context, userID string, step int64) (bool, error)
+	Delete(ctx context.Context, userID string) error
+	ReplaceRecoveryCodes(ctx context.Context, userID string, codes []*RecoveryCode) error
+	UseRecoveryCode(ctx context.Context, userID, codeHash string) (bool, error)
+}
+
+type Service interface {
+	Enroll(ctx context.Context) (*dto.MFAEnrollResponse, error)
+	Confirm(ctx context.Context, code string) (*dto.MFARecoveryCodesResponse, error)
+	Disable(ctx context.Context, code string) error
+	IsEnabled(ctx context.Context, userID string) (bool, error)
+	Verify(ctx context.Context, userID, code string) error
+}
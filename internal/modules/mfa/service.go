@@ -0,0 +1,256 @@
+package mfa
+
+import (
+	"context"
+	"encoding/base64"
+	"msn/internal/infra/http/middlewares"
+	"msn/internal/infra/logging"
+	"msn/internal/modules/user"
+	"msn/pkg/common/dto"
+	"msn/pkg/common/fault"
+	"msn/pkg/utils/totp"
+
+	"github.com/skip2/go-qrcode"
+)
+
+const qrCodeSize = 256
+
+type ServiceConfig struct {
+	MFARepo  Repository
+	UserRepo user.UserRepository
+	Issuer   string
+}
+
+type service struct {
+	mfaRepo  Repository
+	userRepo user.UserRepository
+	issuer   string
+}
+
+func NewService(c ServiceConfig) Service {
+	return &service{
+		mfaRepo:  c.MFARepo,
+		userRepo: c.UserRepo,
+		issuer:   c.Issuer,
+	}
+}
+
+// Enroll creates (or replaces) a pending TOTP secret for the logged in user.
+// 2FA is only enforced after Confirm.
+func (s *service) Enroll(ctx context.Context) (*dto.MFAEnrollResponse, error) {
+	logger := logging.FromContext(ctx)
+
+	c, ok := middlewares.ClaimsFromContext(ctx)
+	if !ok {
+		return nil, fault.NewUnauthorized("access token not provided")
+	}
+
+	current, err := s.mfaRepo.GetByUserID(ctx, c.Subject)
+	if err != nil {
+		logger.ErrorContext(ctx, "db_error",
+			"operation", "mfaRepo.GetByUserID",
+			"user_id", c.Subject,
+			"error", err,
+		)
+		return nil, fault.NewInternalServerError("failed to enroll mfa")
+	}
+	if current != nil && current.IsEnabled() {
+		return nil, fault.NewConflict("mfa is already enabled")
+	}
+
+	u, err := s.userRepo.GetByID(ctx, c.Subject)
+	if err != nil || u == nil {
+		logger.ErrorContext(ctx, "db_error",
+			"operation", "userRepo.GetByID",
+			"user_id", c.Subject,
+			"error", err,
+		)
+		return nil, fault.NewInternalServerError("failed to enroll mfa")
+	}
+
+	enrollment, err := New(u.ID())
+	if err != nil {
+		return nil, err
+	}
+
+	uri := totp.URI(s.issuer, u.Email(), enrollment.Secret)
+	png, err := qrcode.Encode(uri, qrcode.Medium, qrCodeSize)
+	if err != nil {
+		logger.ErrorContext(ctx, "qr_code_generation_failed", "error", err)
+		return nil, fault.NewInternalServerError("failed to enroll mfa")
+	}
+
+	if err := s.mfaRepo.Save(ctx, enrollment); err != nil {
+		logger.ErrorContext(ctx, "db_error",
+			"operation", "mfaRepo.Save",
+			"user_id", u.ID(),
+			"error", err,
+		)
+		return nil, fault.NewInternalServerError("failed to enroll mfa")
+	}
+
+	logger.InfoContext(ctx, "mfa_enrollment_started", "user_id", u.ID())
+
+	return &dto.MFAEnrollResponse{
+		Secret:     enrollment.Secret,
+		OTPAuthURI: uri,
+		QRCodePNG:  "data:image/png;base64," + base64.StdEncoding.EncodeToString(png),
+	}, nil
+}
+
+// Confirm enables 2FA once the user proves their authenticator works and
+// returns the recovery codes, which are never shown again.
+func (s *service) Confirm(ctx context.Context, code string) (*dto.MFARecoveryCodesResponse, error) {
+	logger := logging.FromContext(ctx)
+
+	c, ok := middlewares.ClaimsFromContext(ctx)
+	if !ok {
+		return nil, fault.NewUnauthorized("access token not provided")
+	}
+
+	enrollment, err := s.mfaRepo.GetByUserID(ctx, c.Subject)
+	if err != nil {
+		logger.ErrorContext(ctx, "db_error",
+			"operation", "mfaRepo.GetByUserID",
+			"user_id", c.Subject,
+			"error", err,
+		)
+		return nil, fault.NewInternalServerError("failed to confirm mfa")
+	}
+	if enrollment == nil {
+		return nil, fault.NewNotFound("mfa enrollment not found")
+	}
+	if enrollment.IsEnabled() {
+		return nil, fault.NewConflict("mfa is already enabled")
+	}
+
+	step, ok := enrollment.MatchCode(code)
+	if !ok {
+		return nil, fault.NewUnauthorized("invalid mfa code")
+	}
+
+	codes, plain, err := NewRecoveryCodes(enrollment.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	enrollment.UseStep(step)
+	enrollment.Enable()
+
+	if err := s.mfaRepo.ReplaceRecoveryCodes(ctx, enrollment.UserID, codes); err != nil {
+		logger.ErrorContext(ctx, "db_error",
+			"operation", "mfaRepo.ReplaceRecoveryCodes",
+			"user_id", enrollment.UserID,
+			"error", err,
+		)
+		return nil, fault.NewInternalServerError("failed to confirm mfa")
+	}
+
+	if err := s.mfaRepo.Save(ctx, enrollment); err != nil {
+		logger.ErrorContext(ctx, "db_error",
+			"operation", "mfaRepo.Save",
+			"user_id", enrollment.UserID,
+			"error", err,
+		)
+		return nil, fault.NewInternalServerError("failed to confirm mfa")
+	}
+
+	logger.InfoContext(ctx, "mfa_enabled", "user_id", enrollment.UserID)
+
+	return &dto.MFARecoveryCodesResponse{RecoveryCodes: plain}, nil
+}
+
+func (s *service) Disable(ctx context.Context, code string) error {
+	logger := logging.FromContext(ctx)
+
+	c, ok := middlewares.ClaimsFromContext(ctx)
+	if !ok {
+		return fault.NewUnauthorized("access token not provided")
+	}
+
+	if err := s.Verify(ctx, c.Subject, code); err != nil {
+		return err
+	}
+
+	if err := s.mfaRepo.Delete(ctx, c.Subject); err != nil {
+		logger.ErrorContext(ctx, "db_error",
+			"operation", "mfaRepo.Delete",
+			"user_id", c.Subject,
+			"error", err,
+		)
+		return fault.NewInternalServerError("failed to disable mfa")
+	}
+
+	logger.InfoContext(ctx, "mfa_disabled", "user_id", c.Subject)
+
+	return nil
+}
+
+func (s *service) IsEnabled(ctx context.Context, userID string) (bool, error) {
+	enrollment, err := s.mfaRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return false, fault.NewInternalServerError("failed to retrieve mfa enrollment")
+	}
+
+	return enrollment != nil && enrollment.IsEnabled(), nil
+}
+
+// Verify accepts either a TOTP code or an unused recovery code for a user
+// with 2FA enabled. Both kinds of code can only be used once.
+func (s *service) Verify(ctx context.Context, userID, code string) error {
+	logger := logging.FromContext(ctx)
+
+	enrollment, err := s.mfaRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		logger.ErrorContext(ctx, "db_error",
+			"operation", "mfaRepo.GetByUserID",
+			"user_id", userID,
+			"error", err,
+		)
+		return fault.NewInternalServerError("failed to verify mfa code")
+	}
+	if enrollment == nil || !enrollment.IsEnabled() {
+		return fault.NewBadRequest("mfa is not enabled")
+	}
+
+	if IsRecoveryCode(code) {
+		used, err := s.mfaRepo.UseRecoveryCode(ctx, userID, HashRecoveryCode(code))
+		if err != nil {
+			logger.ErrorContext(ctx, "db_error",
+				"operation", "mfaRepo.UseRecoveryCode",
+				"user_id", userID,
+				"error", err,
+			)
+			return fault.NewInternalServerError("failed to verify mfa code")
+		}
+		if !used {
+			logger.WarnContext(ctx, "invalid_recovery_code", "user_id", userID)
+			return fault.NewUnauthorized("invalid mfa code")
+		}
+
+		logger.InfoContext(ctx, "recovery_code_used", "user_id", userID)
+		return nil
+	}
+
+	step, ok := enrollment.MatchCode(code)
+	if !ok {
+		logger.WarnContext(ctx, "invalid_mfa_code", "user_id", userID)
+		return fault.NewUnauthorized("invalid mfa code")
+	}
+
+	accepted, err := s.mfaRepo.UseStep(ctx, userID, step)
+	if err != nil {
+		logger.ErrorContext(ctx, "db_error",
+			"operation", "mfaRepo.UseStep",
+			"user_id", userID,
+			"error", err,
+		)
+		return fault.NewInternalServerError("failed to verify mfa code")
+	}
+	if !accepted {
+		logger.WarnContext(ctx, "mfa_code_replayed", "user_id", userID)
+		return fault.NewUnauthorized("invalid mfa code")
+	}
+
+	return nil
+}
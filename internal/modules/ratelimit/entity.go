@@ -0,0 +1,103 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limit is a token bucket holding up to Capacity tokens that refills
+// completely every Period.
+type Limit struct {
+	Capacity int
+	Period   time.Duration
+}
+
+// ParseLimit reads a limit written as "<capacity>/<period>", e.g. "10/1m".
+func ParseLimit(v string) (Limit, error) {
+	capacity, period, ok := strings.Cut(strings.TrimSpace(v), "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("invalid rate limit %q, expected <capacity>/<period>", v)
+	}
+
+	c, err := strconv.Atoi(capacity)
+	if err != nil || c <= 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit capacity %q", capacity)
+	}
+
+	p, err := time.ParseDuration(period)
+	if err != nil || p <= 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit period %q", period)
+	}
+
+	return Limit{Capacity: c, Period: p}, nil
+}
+
+// Policy renders the limit for the RateLimit-Policy header.
+func (l Limit) Policy() string {
+	return fmt.Sprintf("%d;w=%d", l.Capacity, int(l.Period.Seconds()))
+}
+
+// rate returns how many tokens are added per second.
+func (l Limit) rate() float64 {
+	return float64(l.Capacity) / l.Period.Seconds()
+}
+
+type Bucket struct {
+	Key       string
+	Tokens    float64
+	UpdatedAt time.Time
+}
+
+func NewBucket(key string, limit Limit, now time.Time) *Bucket {
+	return &Bucket{
+		Key:       key,
+		Tokens:    float64(limit.Capacity),
+		UpdatedAt: now,
+	}
+}
+
+// Result is the outcome of taking a token from a bucket.
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+// Take refills the bucket for the time elapsed since its last update and
+// removes one token if there is one.
+func (b *Bucket) Take(limit Limit, now time.Time) Result {
+	elapsed := now.Sub(b.UpdatedAt).Seconds()
+	if elapsed > 0 {
+		b.Tokens = math.Min(float64(limit.Capacity), b.Tokens+elapsed*limit.rate())
+	}
+	b.UpdatedAt = now
+
+	res := Result{Limit: limit.Capacity}
+	if b.Tokens >= 1 {
+		b.Tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = secondsToDuration((1 - b.Tokens) / limit.rate())
+	}
+
+	res.Remaining = int(math.Floor(b.Tokens))
+	res.Reset = b.FullAt(limit).Sub(now)
+
+	return res
+}
+
+// FullAt returns when the bucket will be back at capacity. A full bucket
+// carries no state and can be discarded from then on.
+func (b *Bucket) FullAt(limit Limit) time.Time {
+	missing := float64(limit.Capacity) - b.Tokens
+	return b.UpdatedAt.Add(secondsToDuration(missing / limit.rate()))
+}
+
+func secondsToDuration(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
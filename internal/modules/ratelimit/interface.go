@@ -0,0 +1,9 @@
+package ratelimit
+
+import "context"
+
+// Store keeps token buckets. Take must be atomic per key so concurrent
+// requests cannot spend the same token.
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit) (*Result, error)
+}
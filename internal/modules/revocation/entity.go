@@ -0,0 +1,14 @@
+package revocation
+
+import "time"
+
+// Entry denies every access token matching Key until ExpiresAt, after which
+// those tokens have expired on their own.
+type Entry struct {
+	Key       string
+	ExpiresAt time.Time
+}
+
+func SessionKey(sessionID string) string {
+	return "sid:" + sessionID
+}
@@ -0,0 +1,15 @@
+package revocation
+
+import "context"
+
+// Store keeps denylist entries and forgets them once they expire.
+type Store interface {
+	Add(ctx context.Context, entry Entry) error
+	Contains(ctx context.Context, keys ...string) (bool, error)
+}
+
+// Service decides whether an access token was revoked before it expired.
+type Service interface {
+	RevokeSession(ctx context.Context, sessionID string) error
+	IsRevoked(ctx context.Context, sessionID string) (bool, error)
+}
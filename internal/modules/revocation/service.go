@@ -0,0 +1,41 @@
+package revocation
+
+import (
+	"context"
+	"time"
+)
+
+type ServiceConfig struct {
+	Store Store
+	// TokenTTL is the lifetime of access tokens, and so how long a revoked
+	// session must stay denied.
+	TokenTTL time.Duration
+}
+
+type service struct {
+	store    Store
+	tokenTTL time.Duration
+}
+
+// NewService returns a denylist backed Service.
+func NewService(c ServiceConfig) Service {
+	return &service{
+		store:    c.Store,
+		tokenTTL: c.TokenTTL,
+	}
+}
+
+func (s *service) RevokeSession(ctx context.Context, sessionID string) error {
+	return s.store.Add(ctx, Entry{
+		Key:       SessionKey(sessionID),
+		ExpiresAt: time.Now().Add(s.tokenTTL),
+	})
+}
+
+func (s *service) IsRevoked(ctx context.Context, sessionID string) (bool, error) {
+	if sessionID == "" {
+		return false, nil
+	}
+
+	return s.store.Contains(ctx, SessionKey(sessionID))
+}
@@ -1,29 +1,37 @@
 package role
 
 import (
+	"fmt"
 	"msn/internal/infra/database/models"
 	"msn/pkg/common/fault"
 	"msn/pkg/utils/uid"
+	"slices"
 	"time"
 )
 
 type Role struct {
-	id        string
-	name      string
-	createdAt time.Time
-	updatedAt *time.Time
-	deletedAt *time.Time
+	id              string
+	name            string
+	subcategoryRule SubcategoryRule
+	selfAssignable  bool
+	permissions     []string
+	createdAt       time.Time
+	updatedAt       *time.Time
+	deletedAt       *time.Time
 }
 
 func New(
 	name string,
 ) (*Role, error) {
 	role := Role{
-		id:        uid.New("role"),
-		name:      name,
-		createdAt: time.Now(),
-		updatedAt: nil,
-		deletedAt: nil,
+		id:              uid.New("role"),
+		name:            name,
+		subcategoryRule: SubcategoryForbidden,
+		selfAssignable:  false,
+		permissions:     nil,
+		createdAt:       time.Now(),
+		updatedAt:       nil,
+		deletedAt:       nil,
 	}
 
 	if err := role.validate(); err != nil {
@@ -37,23 +45,28 @@ func New(
 	return &role, nil
 }
 
-func NewFromModel(m models.Role) *Role {
+func NewFromModel(m models.Role, permissions []string) *Role {
 	return &Role{
-		id:        m.ID,
-		name:      m.Name,
-		createdAt: m.CreatedAt,
-		updatedAt: m.UpdatedAt,
-		deletedAt: m.DeletedAt,
+		id:              m.ID,
+		name:            m.Name,
+		subcategoryRule: SubcategoryRule(m.SubcategoryRule),
+		selfAssignable:  m.SelfAssignable,
+		permissions:     permissions,
+		createdAt:       m.CreatedAt,
+		updatedAt:       m.UpdatedAt,
+		deletedAt:       m.DeletedAt,
 	}
 }
 
 func (r *Role) ToModel() models.Role {
 	return models.Role{
-		ID:        r.ID(),
-		Name:      r.Name(),
-		CreatedAt: r.CreatedAt(),
-		UpdatedAt: r.UpdatedAt(),
-		DeletedAt: r.DeletedAt(),
+		ID:              r.ID(),
+		Name:            r.Name(),
+		SubcategoryRule: string(r.SubcategoryRule()),
+		SelfAssignable:  r.SelfAssignable(),
+		CreatedAt:       r.CreatedAt(),
+		UpdatedAt:       r.UpdatedAt(),
+		DeletedAt:       r.DeletedAt(),
 	}
 }
 
@@ -62,9 +75,35 @@ func (r *Role) validate() error {
 		return fault.NewBadRequest("role name is required")
 	}
 
+	switch r.subcategoryRule {
+	case SubcategoryForbidden, SubcategoryRequired, SubcategoryOptional:
+	default:
+		return fault.NewBadRequest("invalid role subcategory rule")
+	}
+
+	return nil
+}
+
+// ValidateSubcategory checks a user of this role against its subcategory rule.
+func (r *Role) ValidateSubcategory(hasSubcategory bool) error {
+	switch r.subcategoryRule {
+	case SubcategoryRequired:
+		if !hasSubcategory {
+			return fault.NewBadRequest(fmt.Sprintf("%s users must have a subcategory", r.name))
+		}
+	case SubcategoryForbidden:
+		if hasSubcategory {
+			return fault.NewBadRequest(fmt.Sprintf("%s users cannot have subcategories", r.name))
+		}
+	}
+
 	return nil
 }
 
+func (r *Role) HasPermission(permission string) bool {
+	return slices.Contains(r.permissions, permission)
+}
+
 func FromID(id string) *Role {
 	return &Role{id: id}
 }
@@ -77,6 +116,19 @@ func (r *Role) Name() string {
 	return r.name
 }
 
+func (r *Role) SubcategoryRule() SubcategoryRule {
+	return r.subcategoryRule
+}
+
+// SelfAssignable reports whether users may pick this role when they sign up.
+func (r *Role) SelfAssignable() bool {
+	return r.selfAssignable
+}
+
+func (r *Role) Permissions() []string {
+	return r.permissions
+}
+
 func (r *Role) CreatedAt() time.Time {
 	return r.createdAt
 }
@@ -4,4 +4,6 @@ import "context"
 
 type Repository interface {
 	GetRoleByName(ctx context.Context, roleName string) (*Role, error)
+	GetRoleByID(ctx context.Context, roleID string) (*Role, error)
+	AssignToUser(ctx context.Context, userID, roleID string) error
 }
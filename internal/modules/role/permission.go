@@ -0,0 +1,19 @@
+package role
+
+// Permissions a role can grant. They are stored by value in role_permissions
+// and carried in the access token scopes.
+const (
+	PermissionCategoriesWrite = "categories:write"
+	PermissionRolesAssign     = "roles:assign"
+	PermissionUsersUnlock     = "users:unlock"
+	PermissionUsersRestore    = "users:restore"
+)
+
+// SubcategoryRule says whether users of a role are attached to a subcategory.
+type SubcategoryRule string
+
+const (
+	SubcategoryForbidden SubcategoryRule = "forbidden"
+	SubcategoryRequired  SubcategoryRule = "required"
+	SubcategoryOptional  SubcategoryRule = "optional"
+)
@@ -0,0 +1,85 @@
+package session
+
+import (
+	"context"
+	"msn/internal/modules/revocation"
+	"sync"
+	"time"
+)
+
+type checkerEntry struct {
+	active    bool
+	checkedAt time.Time
+}
+
+// activeSessionChecker is the cheap alternative to the denylist: a token is
+// revoked when its session is no longer active. Lookups are cached for ttl,
+// so other instances may accept a revoked token for that long.
+type activeSessionChecker struct {
+	sessionRepo SessionRepository
+	ttl         time.Duration
+
+	mu    sync.Mutex
+	cache map[string]checkerEntry
+}
+
+func NewActiveSessionChecker(sessionRepo SessionRepository, ttl time.Duration) revocation.Service {
+	return &activeSessionChecker{
+		sessionRepo: sessionRepo,
+		ttl:         ttl,
+		cache:       make(map[string]checkerEntry),
+	}
+}
+
+// RevokeSession only drops the cached state; the session itself was already
+// deactivated by the caller.
+func (c *activeSessionChecker) RevokeSession(_ context.Context, sessionID string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.cache, sessionID)
+
+	return nil
+}
+
+func (c *activeSessionChecker) IsRevoked(ctx context.Context, sessionID string) (bool, error) {
+	if sessionID == "" {
+		return false, nil
+	}
+
+	now := time.Now()
+
+	c.mu.Lock()
+	entry, ok := c.cache[sessionID]
+	c.mu.Unlock()
+	if ok && now.Sub(entry.checkedAt) < c.ttl {
+		return !entry.active, nil
+	}
+
+	sess, err := c.sessionRepo.GetByID(ctx, sessionID)
+	if err != nil {
+		return false, err
+	}
+	active := sess != nil && sess.Active && !sess.IsExpired()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.cache) >= checkerCacheLimit {
+		c.sweep(now)
+	}
+	c.cache[sessionID] = checkerEntry{active: active, checkedAt: now}
+
+	return !active, nil
+}
+
+// checkerCacheLimit is the number of cached sessions above which stale
+// entries are dropped on the next write.
+const checkerCacheLimit = 10000
+
+func (c *activeSessionChecker) sweep(now time.Time) {
+	for id, entry := range c.cache {
+		if now.Sub(entry.checkedAt) >= c.ttl {
+			delete(c.cache, id)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package session
 
 import (
 	"msn/internal/infra/database/models"
+	"msn/pkg/common/dto"
 	"msn/pkg/common/fault"
 	"msn/pkg/utils/uid"
 	"time"
@@ -12,52 +13,70 @@ const (
 )
 
 type Session struct {
-	ID        string
-	UserID    string
-	JTI       string
-	Active    bool
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	ExpiresAt time.Time
+	ID          string
+	UserID      string
+	JTI         string
+	Active      bool
+	DeviceLabel string
+	UserAgent   string
+	IPAddress   string
+	LastSeenAt  time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	ExpiresAt   time.Time
 }
 
-func New(userID, JTI string) (*Session, error) {
+func New(userID, JTI, deviceLabel, userAgent, ipAddress string) (*Session, error) {
 	if userID == "" || JTI == "" {
 		return nil, fault.New("userID and JTI are required")
 	}
 
+	now := time.Now()
+
 	return &Session{
-		ID:        uid.New("sess"),
-		UserID:    userID,
-		JTI:       JTI,
-		Active:    true,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
-		ExpiresAt: time.Now().Add(ttl),
+		ID:          uid.New("sess"),
+		UserID:      userID,
+		JTI:         JTI,
+		Active:      true,
+		DeviceLabel: deviceLabel,
+		UserAgent:   userAgent,
+		IPAddress:   ipAddress,
+		LastSeenAt:  now,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		ExpiresAt:   now.Add(ttl),
 	}, nil
 }
 
 func NewFromModel(m models.Session) *Session {
 	return &Session{
-		ID:        m.ID,
-		UserID:    m.UserID,
-		JTI:       m.JTI,
-		Active:    m.Active,
-		CreatedAt: m.CreatedAt,
-		UpdatedAt: m.UpdatedAt,
-		ExpiresAt: m.ExpiresAt,
+		ID:          m.ID,
+		UserID:      m.UserID,
+		JTI:         m.JTI,
+		Active:      m.Active,
+		DeviceLabel: m.DeviceLabel,
+		UserAgent:   m.UserAgent,
+		IPAddress:   m.IPAddress,
+		LastSeenAt:  m.LastSeenAt,
+		CreatedAt:   m.CreatedAt,
+		UpdatedAt:   m.UpdatedAt,
+		ExpiresAt:   m.ExpiresAt,
 	}
 }
 
 func (s *Session) ToModel() models.Session {
 	return models.Session{
-		ID:        s.ID,
-		UserID:    s.UserID,
-		JTI:       s.JTI,
-		Active:    s.Active,
-		CreatedAt: s.CreatedAt,
-		UpdatedAt: s.UpdatedAt,
-		ExpiresAt: s.ExpiresAt,
+		ID:          s.ID,
+		UserID:      s.UserID,
+		JTI:         s.JTI,
+		Active:      s.Active,
+		DeviceLabel: s.DeviceLabel,
+		UserAgent:   s.UserAgent,
+		IPAddress:   s.IPAddress,
+		LastSeenAt:  s.LastSeenAt,
+		CreatedAt:   s.CreatedAt,
+		UpdatedAt:   s.UpdatedAt,
+		ExpiresAt:   s.ExpiresAt,
 	}
 }
 
@@ -70,6 +89,18 @@ func (s *Session) ChangeJTI(JTI string) {
 	s.UpdatedAt = time.Now()
 }
 
+// Touch records that the session was just used from the given client.
+func (s *Session) Touch(userAgent, ipAddress string) {
+	if userAgent != "" {
+		s.UserAgent = userAgent
+	}
+	if ipAddress != "" {
+		s.IPAddress = ipAddress
+	}
+	s.LastSeenAt = time.Now()
+	s.UpdatedAt = s.LastSeenAt
+}
+
 func (s *Session) Activate() {
 	s.Active = true
 	s.UpdatedAt = time.Now()
@@ -79,3 +110,18 @@ func (s *Session) Deactivate() {
 	s.Active = false
 	s.UpdatedAt = time.Now()
 }
+
+func (s *Session) ToResponse(current bool) *dto.SessionResponse {
+	return &dto.SessionResponse{
+		ID:          s.ID,
+		Active:      s.Active,
+		Current:     current,
+		DeviceLabel: s.DeviceLabel,
+		UserAgent:   s.UserAgent,
+		IPAddress:   s.IPAddress,
+		LastSeenAt:  s.LastSeenAt,
+		CreatedAt:   s.CreatedAt,
+		UpdatedAt:   s.UpdatedAt,
+		ExpiresAt:   s.ExpiresAt,
+	}
+}
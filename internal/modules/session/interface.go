@@ -8,15 +8,23 @@ import (
 type SessionService interface {
 	CreateSession(ctx context.Context, input dto.CreateSession) (*Session, error)
 	DeactivateAllSessions(ctx context.Context, userID string) error
-	GetActiveSessionByUserID(ctx context.Context, userID string) (*Session, error)
+	GetSessionByID(ctx context.Context, ID string) (*Session, error)
+	GetActiveSessionsByUserID(ctx context.Context, userID string) ([]*Session, error)
+	RevokeOtherSessions(ctx context.Context, userID, currentSessionID string) (int, error)
+	GetSessionByJTI(ctx context.Context, JTI string) (*Session, error)
+	GetSessionByRotatedJTI(ctx context.Context, JTI string) (*Session, error)
 	UpdateSession(ctx context.Context, session *Session) (*Session, error)
+	RotateSession(ctx context.Context, session *Session, JTI string) (*Session, error)
+	RevokeSessionFamily(ctx context.Context, session *Session) error
 }
 
 type SessionRepository interface {
 	Create(ctx context.Context, session *Session) error
 	Update(ctx context.Context, session *Session) error
 	GetAllByUserID(ctx context.Context, userID string) ([]*Session, error)
-	GetActiveByUserID(ctx context.Context, userID string) (*Session, error)
+	GetByID(ctx context.Context, ID string) (*Session, error)
 	GetByJTI(ctx context.Context, JTI string) (*Session, error)
+	GetByRotatedJTI(ctx context.Context, JTI string) (*Session, error)
+	Rotate(ctx context.Context, session *Session, previousJTI string) error
 	DeactivateAll(ctx context.Context, userID string) error
 }
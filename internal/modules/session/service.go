@@ -2,6 +2,8 @@ package session
 
 import (
 	"context"
+	"msn/internal/infra/logging"
+	"msn/internal/modules/revocation"
 	"msn/internal/modules/user"
 	"msn/pkg/common/dto"
 	"msn/pkg/common/fault"
@@ -10,22 +12,31 @@ import (
 type ServiceConfig struct {
 	SessionRepo SessionRepository
 	UserService user.UserService
+	Revocations revocation.Service
 }
 
 type service struct {
 	sessionRepo SessionRepository
 	userService user.UserService
+	revocations revocation.Service
 }
 
 func NewService(c ServiceConfig) SessionService {
 	return &service{
 		sessionRepo: c.SessionRepo,
 		userService: c.UserService,
+		revocations: c.Revocations,
 	}
 }
 
 func (s service) CreateSession(ctx context.Context, input dto.CreateSession) (*Session, error) {
-	sess, err := New(input.UserID, input.JTI)
+	sess, err := New(
+		input.UserID,
+		input.JTI,
+		input.Device.Label,
+		input.Device.UserAgent,
+		input.Device.IPAddress,
+	)
 	if err != nil {
 		return nil, fault.NewUnprocessableEntity("failed to create session entity")
 	}
@@ -39,28 +50,158 @@ func (s service) CreateSession(ctx context.Context, input dto.CreateSession) (*S
 }
 
 func (s service) DeactivateAllSessions(ctx context.Context, userID string) error {
-	err := s.sessionRepo.DeactivateAll(ctx, userID)
+	sessions, err := s.GetActiveSessionsByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	err = s.sessionRepo.DeactivateAll(ctx, userID)
 	if err != nil {
 		return fault.NewBadRequest("failed to deactivate all user sessions")
 	}
 
+	for _, sess := range sessions {
+		s.revokeAccessTokens(ctx, sess.ID)
+	}
+
 	return nil
 }
 
-func (s service) GetActiveSessionByUserID(ctx context.Context, userID string) (*Session, error) {
-	sess, err := s.sessionRepo.GetActiveByUserID(ctx, userID)
+func (s service) GetSessionByID(ctx context.Context, ID string) (*Session, error) {
+	sess, err := s.sessionRepo.GetByID(ctx, ID)
 	if err != nil {
-		return nil, fault.NewBadRequest("failed to get activer user sessions")
+		return nil, fault.NewBadRequest("failed to get session")
 	}
 
 	return sess, nil
 }
 
+// UpdateSession saves the session. Access tokens of a session saved as
+// inactive are revoked.
 func (s service) UpdateSession(ctx context.Context, session *Session) (*Session, error) {
 	err := s.sessionRepo.Update(ctx, session)
 	if err != nil {
 		return nil, fault.NewBadRequest("failed to update session")
 	}
 
+	if !session.Active {
+		s.revokeAccessTokens(ctx, session.ID)
+	}
+
+	return session, nil
+}
+
+func (s service) GetSessionByJTI(ctx context.Context, JTI string) (*Session, error) {
+	sess, err := s.sessionRepo.GetByJTI(ctx, JTI)
+	if err != nil {
+		return nil, fault.NewBadRequest("failed to get session by jti")
+	}
+
+	return sess, nil
+}
+
+func (s service) GetSessionByRotatedJTI(ctx context.Context, JTI string) (*Session, error) {
+	sess, err := s.sessionRepo.GetByRotatedJTI(ctx, JTI)
+	if err != nil {
+		return nil, fault.NewBadRequest("failed to get session by rotated jti")
+	}
+
+	return sess, nil
+}
+
+// RotateSession binds the session to a freshly issued refresh token JTI.
+// The previous JTI is kept so a replay of the old token can be traced back
+// to its session.
+func (s service) RotateSession(ctx context.Context, session *Session, JTI string) (*Session, error) {
+	previousJTI := session.JTI
+	session.ChangeJTI(JTI)
+
+	err := s.sessionRepo.Rotate(ctx, session, previousJTI)
+	if err != nil {
+		session.ChangeJTI(previousJTI)
+		// A conflict means another request rotated the token first; anything
+		// else is a database failure the client cannot fix by logging in.
+		if fault.GetTag(err) == fault.CONFLICT {
+			return nil, fault.NewConflict("session was rotated concurrently")
+		}
+
+		logging.FromContext(ctx).ErrorContext(ctx, "db_error",
+			"operation", "sessionRepo.Rotate",
+			"session_id", session.ID,
+			"error", err,
+		)
+		return nil, fault.NewInternalServerError("failed to rotate session")
+	}
+
 	return session, nil
 }
+
+// RevokeSessionFamily deactivates the session that every refresh token
+// rotated from the same login belongs to.
+func (s service) RevokeSessionFamily(ctx context.Context, session *Session) error {
+	session.Deactivate()
+
+	err := s.sessionRepo.Update(ctx, session)
+	if err != nil {
+		return fault.NewBadRequest("failed to revoke session family")
+	}
+
+	s.revokeAccessTokens(ctx, session.ID)
+
+	return nil
+}
+
+// GetActiveSessionsByUserID returns the sessions of the user that can still
+// be refreshed, most recent first.
+func (s service) GetActiveSessionsByUserID(ctx context.Context, userID string) ([]*Session, error) {
+	sessions, err := s.sessionRepo.GetAllByUserID(ctx, userID)
+	if err != nil {
+		return nil, fault.NewBadRequest("failed to get user sessions")
+	}
+
+	active := make([]*Session, 0, len(sessions))
+	for _, sess := range sessions {
+		if sess.Active && !sess.IsExpired() {
+			active = append(active, sess)
+		}
+	}
+
+	return active, nil
+}
+
+// RevokeOtherSessions deactivates every active session of the user except
+// the current one and returns how many were revoked.
+func (s service) RevokeOtherSessions(ctx context.Context, userID, currentSessionID string) (int, error) {
+	sessions, err := s.GetActiveSessionsByUserID(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	revoked := 0
+	for _, sess := range sessions {
+		if sess.ID == currentSessionID {
+			continue
+		}
+
+		sess.Deactivate()
+		if err := s.sessionRepo.Update(ctx, sess); err != nil {
+			return revoked, fault.NewBadRequest("failed to revoke session")
+		}
+		s.revokeAccessTokens(ctx, sess.ID)
+		revoked++
+	}
+
+	return revoked, nil
+}
+
+// revokeAccessTokens denies the access tokens already issued for a session
+// that was just deactivated. The session itself is gone either way, so a
+// failure is only logged and the tokens expire on their own.
+func (s service) revokeAccessTokens(ctx context.Context, sessionID string) {
+	if err := s.revocations.RevokeSession(ctx, sessionID); err != nil {
+		logging.FromContext(ctx).ErrorContext(ctx, "access_token_revocation_failed",
+			"session_id", sessionID,
+			"error", err,
+		)
+	}
+}
@@ -0,0 +1,143 @@
+package user
+
+import (
+	"context"
+	"msn/internal/infra/http/middlewares"
+	"msn/internal/infra/logging"
+	"msn/pkg/common/dto"
+	"msn/pkg/common/fault"
+	"time"
+)
+
+const (
+	// DefaultDeletionGrace is how long a deleted account can be restored
+	// before its personal data is anonymized.
+	DefaultDeletionGrace = 30 * 24 * time.Hour
+	// DefaultAnonymizeInterval is how often deleted accounts past the grace
+	// period are looked for.
+	DefaultAnonymizeInterval = time.Hour
+)
+
+// anonymizeBatchSize bounds how many users a single run anonymizes.
+const anonymizeBatchSize = 100
+
+// RestoreUser reactivates an account deleted less than the grace period
+// ago. The user logs in again with the same email and password.
+func (s service) RestoreUser(ctx context.Context, userID string) (*dto.UserResponse, error) {
+	logger := logging.FromContext(ctx)
+
+	user, err := s.userRepo.GetDeletedByID(ctx, userID)
+	if err != nil {
+		logger.ErrorContext(ctx, "db_error",
+			"operation", "userRepo.GetDeletedByID",
+			"user_id", userID,
+			"error", err,
+		)
+		return nil, fault.NewInternalServerError("failed to retrieve user")
+	}
+	if user == nil {
+		return nil, fault.NewNotFound("deleted user not found")
+	}
+
+	deletedAfter := time.Now().Add(-s.deletionGrace)
+	if err := user.Restore(deletedAfter); err != nil {
+		return nil, err
+	}
+
+	ok, err := s.userRepo.Restore(ctx, user, deletedAfter)
+	if err != nil {
+		logger.ErrorContext(ctx, "db_error",
+			"operation", "userRepo.Restore",
+			"user_id", user.ID(),
+			"error", err,
+		)
+		return nil, fault.NewInternalServerError("failed to restore user")
+	}
+	if !ok {
+		return nil, fault.NewConflict("user can no longer be restored")
+	}
+
+	actorID := ""
+	if c, ok := middlewares.ClaimsFromContext(ctx); ok {
+		actorID = c.Subject
+	}
+	logger.InfoContext(ctx, "security_event",
+		"event", "user_restored",
+		"user_id", user.ID(),
+		"actor_id", actorID,
+	)
+
+	return user.ToResponse(), nil
+}
+
+// AnonymizeDeletedUsers removes the personal data and the avatar of the
+// accounts deleted more than the grace period ago. It is meant to run
+// periodically and stops at the first database failure, leaving the rest to
+// the next run.
+func (s service) AnonymizeDeletedUsers(ctx context.Context) error {
+	logger := logging.FromContext(ctx)
+
+	deletedBefore := time.Now().Add(-s.deletionGrace)
+	users, err := s.userRepo.GetPendingAnonymization(ctx, deletedBefore, anonymizeBatchSize)
+	if err != nil {
+		logger.ErrorContext(ctx, "db_error",
+			"operation", "userRepo.GetPendingAnonymization",
+			"error", err,
+		)
+		return fault.NewInternalServerError("failed to retrieve deleted users")
+	}
+
+	for _, user := range users {
+		// Anonymizing first claims the row, so a restore can no longer race
+		// the avatar removal. The avatar URL is kept until the object is gone
+		// and a user whose avatar cannot be removed is retried on the next
+		// run.
+		if user.AnonymizedAt() == nil {
+			ok, err := s.userRepo.Anonymize(ctx, user.ID(), deletedBefore)
+			if err != nil {
+				logger.ErrorContext(ctx, "db_error",
+					"operation", "userRepo.Anonymize",
+					"user_id", user.ID(),
+					"error", err,
+				)
+				return fault.NewInternalServerError("failed to anonymize user")
+			}
+			if !ok {
+				// Restored or anonymized by another instance in the meantime.
+				continue
+			}
+
+			logger.InfoContext(ctx, "security_event",
+				"event", "user_anonymized",
+				"user_id", user.ID(),
+				"deleted_at", user.DeletedAt(),
+			)
+		}
+
+		if user.AvatarURL() == "" {
+			continue
+		}
+
+		if bucket, objectName, ok := AvatarObject(user.AvatarURL()); ok {
+			if err := s.storageClient.RemoveFile(bucket, objectName); err != nil {
+				logger.ErrorContext(ctx, "avatar_removal_failed",
+					"user_id", user.ID(),
+					"object", objectName,
+					"error", err,
+				)
+				continue
+			}
+		}
+
+		if err := s.userRepo.ClearAvatar(ctx, user.ID()); err != nil {
+			logger.ErrorContext(ctx, "db_error",
+				"operation", "userRepo.ClearAvatar",
+				"user_id", user.ID(),
+				"error", err,
+			)
+			return fault.NewInternalServerError("failed to anonymize user")
+		}
+	}
+
+	return nil
+}
@@ -2,11 +2,13 @@ package user
 
 import (
 	"msn/internal/infra/database/models"
+	"msn/internal/modules/category"
 	"msn/internal/modules/role"
-	"msn/internal/modules/subcategory"
 	"msn/pkg/common/dto"
 	"msn/pkg/common/fault"
 	"msn/pkg/common/valueobjects"
+	"msn/pkg/utils/crypto"
+	"strings"
 	"time"
 )
 
@@ -14,17 +16,22 @@ type User struct {
 	id           string
 	name         string
 	email        valueobjects.Email
+	pendingEmail *string // new email waiting for confirmation
 	passwordHash string
 	avatarURL    string
 	role         role.Role
-	subcategory  *subcategory.Subcategory
+	subcategory  *category.Category // leaf node a professional works in
+	verifiedAt   *time.Time
 	createdAt    time.Time
 	updatedAt    *time.Time
 	deletedAt    *time.Time
+	anonymizedAt *time.Time // set once the personal data of a deleted user is gone
+	version      int        // bumped by every profile update
 }
 
 func New(
-	ID, name, rawEmail, hashedPassword, avatarURL, roleID string,
+	ID, name, rawEmail, hashedPassword, avatarURL string,
+	userRole *role.Role,
 	subcatID *string,
 ) (*User, error) {
 	emailVO, err := valueobjects.NewEmail(rawEmail)
@@ -44,15 +51,17 @@ func New(
 		email:        emailVO,
 		passwordHash: hashedPassword,
 		avatarURL:    avatarURL,
-		role:         *role.FromID(roleID),
+		role:         *userRole,
 		subcategory:  nil,
+		verifiedAt:   nil,
 		createdAt:    now,
 		updatedAt:    nil,
 		deletedAt:    nil,
+		version:      1,
 	}
 
 	if subcatID != nil {
-		user.subcategory = subcategory.FromID(*subcatID)
+		user.subcategory = category.FromID(*subcatID)
 	}
 
 	if err := user.validate(); err != nil {
@@ -72,17 +81,21 @@ func NewFromModel(m models.User) (*User, error) {
 		id:           m.ID,
 		name:         m.Name,
 		email:        emailVO,
+		pendingEmail: m.PendingEmail,
 		passwordHash: m.Password,
 		avatarURL:    m.AvatarURL,
 		role:         *role.FromID(m.RoleID),
 		subcategory:  nil,
+		verifiedAt:   m.VerifiedAt,
 		createdAt:    m.CreatedAt,
 		updatedAt:    m.UpdatedAt,
 		deletedAt:    m.DeletedAt,
+		anonymizedAt: m.AnonymizedAt,
+		version:      m.Version,
 	}
 
 	if m.SubcategoryID != nil {
-		user.subcategory = subcategory.FromID(*m.SubcategoryID)
+		user.subcategory = category.FromID(*m.SubcategoryID)
 	}
 
 	return user, nil
@@ -99,13 +112,17 @@ func (u *User) ToModel() models.User {
 		ID:            u.id,
 		Name:          u.name,
 		Email:         u.email.Value,
+		PendingEmail:  u.pendingEmail,
 		Password:      u.passwordHash,
 		AvatarURL:     u.avatarURL,
 		RoleID:        u.role.ID(),
 		SubcategoryID: subcatID,
+		VerifiedAt:    u.verifiedAt,
 		CreatedAt:     u.createdAt,
 		UpdatedAt:     u.updatedAt,
 		DeletedAt:     u.deletedAt,
+		AnonymizedAt:  u.anonymizedAt,
+		Version:       u.version,
 	}
 }
 
@@ -118,19 +135,115 @@ func (u *User) validate() error {
 		return fault.NewBadRequest("user role is required")
 	}
 
-	switch u.role.ID() {
-	case "client":
-		if u.subcategory != nil {
-			return fault.NewBadRequest("clients cannot have subcategories")
-		}
-	case "professional":
-		if u.subcategory == nil {
-			return fault.NewBadRequest("professionals must have a subcategory")
+	return u.role.ValidateSubcategory(u.subcategory != nil)
+}
+
+// ChangeRole moves the user to another role, as long as the user still
+// satisfies its rules.
+func (u *User) ChangeRole(r *role.Role) error {
+	previous := u.role
+	u.role = *r
+
+	if err := u.validate(); err != nil {
+		u.role = previous
+		return err
+	}
+
+	return nil
+}
+
+// ProfileChanges lists the fields a user can change in their profile. Nil
+// fields are left as they are.
+type ProfileChanges struct {
+	Name      *string
+	AvatarURL *string
+	// SubcategoryID moves the user to another leaf category, or removes it
+	// when empty.
+	SubcategoryID *string
+}
+
+// UpdateProfile applies changes under the rules of userRole, the user's role
+// loaded with its rules, and keeps the profile as it was if the result is
+// invalid.
+func (u *User) UpdateProfile(userRole *role.Role, changes ProfileChanges) error {
+	previous := *u
+	u.role = *userRole
+
+	if changes.Name != nil {
+		u.name = *changes.Name
+	}
+	if changes.AvatarURL != nil {
+		u.avatarURL = *changes.AvatarURL
+	}
+	if changes.SubcategoryID != nil {
+		u.subcategory = nil
+		if *changes.SubcategoryID != "" {
+			u.subcategory = category.FromID(*changes.SubcategoryID)
 		}
 	}
+
+	if err := u.validate(); err != nil {
+		*u = previous
+		return err
+	}
+
+	now := time.Now()
+	u.updatedAt = &now
+	return nil
+}
+
+// RequestEmailChange keeps rawEmail as the pending email of the user. The
+// email in use only changes once the new one is confirmed.
+func (u *User) RequestEmailChange(rawEmail string) error {
+	emailVO, err := valueobjects.NewEmail(rawEmail)
+	if err != nil {
+		return fault.NewBadRequest(err.Error())
+	}
+	if strings.EqualFold(emailVO.Value, u.email.Value) {
+		return fault.NewBadRequest("new email must be different from the current one")
+	}
+
+	now := time.Now()
+	u.pendingEmail = &emailVO.Value
+	u.updatedAt = &now
+	return nil
+}
+
+// Delete schedules the account for removal. Its personal data stays until
+// it is anonymized, and until then the account can be restored.
+func (u *User) Delete() error {
+	if u.deletedAt != nil {
+		return fault.NewConflict("user already deleted")
+	}
+
+	now := time.Now()
+	u.pendingEmail = nil
+	u.deletedAt = &now
+	u.updatedAt = &now
+	return nil
+}
+
+// Restore reactivates an account deleted after deletedAfter, the start of
+// the grace period, that was not anonymized yet.
+func (u *User) Restore(deletedAfter time.Time) error {
+	if u.deletedAt == nil {
+		return fault.NewConflict("user is not deleted")
+	}
+	if u.anonymizedAt != nil || u.deletedAt.Before(deletedAfter) {
+		return fault.NewConflict("user can no longer be restored")
+	}
+
+	now := time.Now()
+	u.deletedAt = nil
+	u.updatedAt = &now
 	return nil
 }
 
+// PasswordMatches reports whether plain is the password of the user.
+func (u *User) PasswordMatches(plain string) bool {
+	return crypto.PasswordMatches(plain, u.passwordHash)
+}
+
 func (u *User) ID() string {
 	return u.id
 }
@@ -143,6 +256,10 @@ func (u *User) Email() string {
 	return u.email.Value
 }
 
+func (u *User) PendingEmail() *string {
+	return u.pendingEmail
+}
+
 func (u *User) AvatarURL() string {
 	return u.avatarURL
 }
@@ -151,10 +268,18 @@ func (u *User) Role() role.Role {
 	return u.role
 }
 
-func (u *User) Subcategory() *subcategory.Subcategory {
+func (u *User) Subcategory() *category.Category {
 	return u.subcategory
 }
 
+func (u *User) VerifiedAt() *time.Time {
+	return u.verifiedAt
+}
+
+func (u *User) IsVerified() bool {
+	return u.verifiedAt != nil
+}
+
 func (u *User) CreatedAt() time.Time {
 	return u.createdAt
 }
@@ -163,6 +288,14 @@ func (u *User) DeletedAt() *time.Time {
 	return u.deletedAt
 }
 
+func (u *User) AnonymizedAt() *time.Time {
+	return u.anonymizedAt
+}
+
+func (u *User) Version() int {
+	return u.version
+}
+
 func (u *User) ToResponse() *dto.UserResponse {
 	var subcatID *string
 	if u.subcategory != nil {
@@ -177,6 +310,7 @@ func (u *User) ToResponse() *dto.UserResponse {
 		AvatarURL:     u.AvatarURL(),
 		RoleID:        u.role.ID(),
 		SubcategoryID: subcatID,
+		VerifiedAt:    u.VerifiedAt(),
 		CreatedAt:     u.CreatedAt(),
 	}
 }
@@ -3,16 +3,50 @@ package user
 import (
 	"context"
 	"msn/pkg/common/dto"
+	"time"
 )
 
 type UserRepository interface {
 	Create(ctx context.Context, user *User) error
-	// Update(ctx context.Context, user model.User) error
+	// Update saves the profile of the user if it is still at the version it
+	// was read with, bumping it. It returns false otherwise.
+	Update(ctx context.Context, user *User) (bool, error)
+	// ChangePassword replaces the password of the user, moving the previous
+	// hash to the password history, of which only the latest keep are kept.
+	ChangePassword(ctx context.Context, userID, hashedPassword string, keep int) error
+	// GetPasswordHistory returns the latest limit previous password hashes of
+	// the user, newest first.
+	GetPasswordHistory(ctx context.Context, userID string, limit int) ([]string, error)
+	SavePendingEmail(ctx context.Context, user *User) error
+	// ConfirmPendingEmail makes the pending email of the user its email. It
+	// returns false when there is no pending email.
+	ConfirmPendingEmail(ctx context.Context, userID string) (bool, error)
+	MarkVerified(ctx context.Context, userID string, verifiedAt time.Time) error
+	// GetByID, GetByEmail and the enriched reads only return users that are
+	// not deleted.
 	GetByID(ctx context.Context, userId string) (*User, error)
 	GetByEmail(ctx context.Context, email string) (*User, error)
+	GetDeletedByID(ctx context.Context, userID string) (*User, error)
+	// GetPendingAnonymization returns up to limit users deleted before
+	// deletedBefore whose personal data is still stored, and anonymized users
+	// whose avatar was not removed yet, oldest first.
+	GetPendingAnonymization(ctx context.Context, deletedBefore time.Time, limit int) ([]*User, error)
+	// Delete returns false when the user was already deleted.
+	Delete(ctx context.Context, user *User) (bool, error)
+	// Restore returns false when the user is not deleted, was deleted before
+	// deletedAfter or was anonymized.
+	Restore(ctx context.Context, user *User, deletedAfter time.Time) (bool, error)
+	// Anonymize replaces the personal data of a user deleted before
+	// deletedBefore and removes its sessions, tokens and 2FA secrets. The
+	// avatar URL is kept until ClearAvatar, so the object can still be
+	// found. It returns false when there was nothing to anonymize.
+	Anonymize(ctx context.Context, userID string, deletedBefore time.Time) (bool, error)
+	// ClearAvatar drops the avatar URL of an anonymized user once the object
+	// is removed.
+	ClearAvatar(ctx context.Context, userID string) error
 	GetEnrichedByEmail(ctx context.Context, email string) (*dto.EnrichedUserResponse, error)
+	GetEnrichedByID(ctx context.Context, userID string) (*dto.EnrichedUserResponse, error)
 	GetProfessionalUsers(ctx context.Context) ([]*dto.ProfessionalUserResponse, error)
-	// Delete(ctx context.Context, userId string) error
 }
 
 type UserService interface {
@@ -20,4 +54,11 @@ type UserService interface {
 	GetUserByID(ctx context.Context, userId string) (*dto.UserResponse, error)
 	CreateUser(ctx context.Context, input dto.CreateUser) (*dto.UserResponse, error)
 	GetProfessionalUsers(ctx context.Context) ([]*dto.ProfessionalUserResponse, error)
+	GetMe(ctx context.Context) (*dto.EnrichedUserResponse, error)
+	UpdateMe(ctx context.Context, input dto.UpdateProfile) (*dto.EnrichedUserResponse, error)
+	AssignRole(ctx context.Context, userID, roleName string) (*dto.UserResponse, error)
+	VerifyEmail(ctx context.Context, token string) error
+	ResendVerification(ctx context.Context, email string) error
+	RestoreUser(ctx context.Context, userID string) (*dto.UserResponse, error)
+	AnonymizeDeletedUsers(ctx context.Context) error
 }
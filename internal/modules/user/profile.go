@@ -0,0 +1,135 @@
+package user
+
+import (
+	"context"
+	"msn/internal/infra/http/middlewares"
+	"msn/internal/infra/logging"
+	"msn/pkg/common/dto"
+	"msn/pkg/common/fault"
+)
+
+// GetMe loads the profile of the logged in user. Access tokens only carry
+// the user ID, so the profile is always read fresh.
+func (s service) GetMe(ctx context.Context) (*dto.EnrichedUserResponse, error) {
+	logger := logging.FromContext(ctx)
+
+	c, ok := middlewares.ClaimsFromContext(ctx)
+	if !ok {
+		return nil, fault.NewUnauthorized("access token not provided")
+	}
+
+	user, err := s.userRepo.GetEnrichedByID(ctx, c.Subject)
+	if err != nil {
+		logger.ErrorContext(ctx, "db_error",
+			"operation", "userRepo.GetEnrichedByID",
+			"user_id", c.Subject,
+			"error", err,
+		)
+		return nil, fault.NewInternalServerError("failed to retrieve user")
+	}
+
+	if user == nil || user.DeletedAt != nil {
+		return nil, fault.NewNotFound("user not found")
+	}
+
+	return user, nil
+}
+
+// UpdateMe applies a partial update to the profile of the logged in user.
+// The update is refused with a conflict when the profile changed after the
+// client read input.Version. A new picture replaces the stored one, which is
+// removed once the update is saved.
+func (s service) UpdateMe(ctx context.Context, input dto.UpdateProfile) (*dto.EnrichedUserResponse, error) {
+	logger := logging.FromContext(ctx)
+
+	c, ok := middlewares.ClaimsFromContext(ctx)
+	if !ok {
+		return nil, fault.NewUnauthorized("access token not provided")
+	}
+	if input.Version == nil {
+		return nil, fault.NewBadRequest("version is required")
+	}
+
+	user, err := s.userRepo.GetByID(ctx, c.Subject)
+	if err != nil {
+		logger.ErrorContext(ctx, "db_error",
+			"operation", "userRepo.GetByID",
+			"user_id", c.Subject,
+			"error", err,
+		)
+		return nil, fault.NewInternalServerError("failed to retrieve user")
+	}
+	if user == nil || user.DeletedAt() != nil {
+		return nil, fault.NewNotFound("user not found")
+	}
+	if user.Version() != *input.Version {
+		return nil, fault.NewConflict("profile was changed by another request, reload it and try again")
+	}
+
+	currentRole := user.Role()
+	userRole, err := s.roleRepo.GetRoleByID(ctx, currentRole.ID())
+	if err != nil || userRole == nil {
+		return nil, fault.NewInternalServerError("failed to retrieve user role")
+	}
+
+	if input.SubcategoryID != nil && *input.SubcategoryID != "" {
+		if err := s.validateSubcategory(ctx, *input.SubcategoryID); err != nil {
+			return nil, err
+		}
+	}
+
+	changes := ProfileChanges{
+		Name:          input.Name,
+		SubcategoryID: input.SubcategoryID,
+	}
+	if input.FileHeader != nil {
+		avatarURL, err := s.UploadUserPicture(ctx, user.ID(), input.FileHeader)
+		if err != nil {
+			logger.ErrorContext(ctx, "avatar_upload_failed",
+				"user_id", user.ID(),
+				"error", err,
+			)
+			return nil, fault.NewInternalServerError("failed to upload avatar")
+		}
+		changes.AvatarURL = &avatarURL
+	}
+
+	previousAvatarURL := user.AvatarURL()
+	// discardAvatar removes a picture uploaded for an update that failed.
+	discardAvatar := func() {
+		if changes.AvatarURL != nil {
+			s.RemoveUserPicture(ctx, *changes.AvatarURL)
+		}
+	}
+
+	if err := user.UpdateProfile(userRole, changes); err != nil {
+		discardAvatar()
+		return nil, fault.NewUnprocessableEntity(fault.Message(err))
+	}
+
+	updated, err := s.userRepo.Update(ctx, user)
+	if err != nil {
+		discardAvatar()
+		logger.ErrorContext(ctx, "db_error",
+			"operation", "userRepo.Update",
+			"user_id", user.ID(),
+			"error", err,
+		)
+		return nil, fault.NewInternalServerError("failed to update user")
+	}
+	if !updated {
+		discardAvatar()
+		return nil, fault.NewConflict("profile was changed by another request, reload it and try again")
+	}
+
+	if changes.AvatarURL != nil && previousAvatarURL != *changes.AvatarURL {
+		s.RemoveUserPicture(ctx, previousAvatarURL)
+	}
+
+	logger.InfoContext(ctx, "user_profile_updated",
+		"user_id", user.ID(),
+		"avatar_replaced", changes.AvatarURL != nil,
+	)
+
+	return s.GetMe(ctx)
+}
@@ -0,0 +1,59 @@
+package user
+
+import (
+	"context"
+	"msn/internal/infra/http/middlewares"
+	"msn/internal/infra/logging"
+	"msn/pkg/common/dto"
+	"msn/pkg/common/fault"
+)
+
+// AssignRole moves a user to another role. The new permissions reach the
+// user's access tokens on their next refresh.
+func (s service) AssignRole(ctx context.Context, userID, roleName string) (*dto.UserResponse, error) {
+	logger := logging.FromContext(ctx)
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fault.NewInternalServerError("failed to retrieve user")
+	}
+	if user == nil || user.DeletedAt() != nil {
+		return nil, fault.NewNotFound("user not found")
+	}
+
+	newRole, err := s.roleRepo.GetRoleByName(ctx, roleName)
+	if err != nil {
+		return nil, fault.NewInternalServerError("failed to retrieve role")
+	}
+	if newRole == nil {
+		return nil, fault.NewNotFound("role not found")
+	}
+
+	previousRole := user.Role()
+	if err := user.ChangeRole(newRole); err != nil {
+		return nil, fault.NewUnprocessableEntity(err.Error())
+	}
+
+	if err := s.roleRepo.AssignToUser(ctx, user.ID(), newRole.ID()); err != nil {
+		logger.ErrorContext(ctx, "db_error",
+			"operation", "roleRepo.AssignToUser",
+			"user_id", user.ID(),
+			"error", err,
+		)
+		return nil, fault.NewInternalServerError("failed to assign role")
+	}
+
+	actorID := ""
+	if c, ok := middlewares.ClaimsFromContext(ctx); ok {
+		actorID = c.Subject
+	}
+	logger.InfoContext(ctx, "security_event",
+		"event", "role_assigned",
+		"user_id", user.ID(),
+		"previous_role_id", previousRole.ID(),
+		"role", newRole.Name(),
+		"actor_id", actorID,
+	)
+
+	return user.ToResponse(), nil
+}
@@ -7,38 +7,58 @@ import (
 	"mime/multipart"
 	"msn/internal/config"
 	"msn/internal/infra/logging"
+	"msn/internal/infra/mailer"
 	"msn/internal/infra/storage"
 	"msn/internal/modules/category"
 	"msn/internal/modules/role"
+	"msn/internal/modules/usertoken"
 	"msn/pkg/common/dto"
 	"msn/pkg/common/fault"
 	"msn/pkg/common/valueobjects"
 	"msn/pkg/utils/dbutil"
 	"msn/pkg/utils/uid"
+	"strings"
+	"time"
 
 	"github.com/lib/pq"
 )
 
 type ServiceConfig struct {
 	UserRepo      UserRepository
+	UserTokenRepo usertoken.Repository
 	CategoryRepo  category.Repository
 	RoleRepo      role.Repository
 	StorageClient *storage.StorageClient
+	Mailer        mailer.Mailer
+	// DeletionGrace is how long a deleted account waits before being
+	// anonymized, DefaultDeletionGrace when not positive.
+	DeletionGrace time.Duration
 }
 
 type service struct {
 	userRepo      UserRepository
+	userTokenRepo usertoken.Repository
 	categoryRepo  category.Repository
 	roleRepo      role.Repository
 	storageClient *storage.StorageClient
+	mailer        mailer.Mailer
+	deletionGrace time.Duration
 }
 
 func NewService(c ServiceConfig) UserService {
+	deletionGrace := c.DeletionGrace
+	if deletionGrace <= 0 {
+		deletionGrace = DefaultDeletionGrace
+	}
+
 	return &service{
 		userRepo:      c.UserRepo,
+		userTokenRepo: c.UserTokenRepo,
 		categoryRepo:  c.CategoryRepo,
 		roleRepo:      c.RoleRepo,
 		storageClient: c.StorageClient,
+		mailer:        c.Mailer,
+		deletionGrace: deletionGrace,
 	}
 }
 
@@ -62,6 +82,15 @@ func (s service) CreateUser(ctx context.Context, input dto.CreateUser) (*dto.Use
 	if err != nil {
 		return nil, fault.NewInternalServerError("failed to validate user role")
 	}
+	if role == nil || !role.SelfAssignable() {
+		return nil, fault.NewBadRequest("invalid user role")
+	}
+
+	if input.SubcategoryID != nil {
+		if err := s.validateSubcategory(ctx, *input.SubcategoryID); err != nil {
+			return nil, err
+		}
+	}
 
 	password, err := valueobjects.NewPassword(input.Password)
 	if err != nil {
@@ -81,7 +110,7 @@ func (s service) CreateUser(ctx context.Context, input dto.CreateUser) (*dto.Use
 		input.Email,
 		password.Hash,
 		avatarURL,
-		role.ID(),
+		role,
 		input.SubcategoryID,
 	)
 	if err != nil {
@@ -114,9 +143,40 @@ func (s service) CreateUser(ctx context.Context, input dto.CreateUser) (*dto.Use
 		"email", user.Email,
 	)
 
+	// The account already exists at this point; if the email cannot be sent
+	// the user can still ask for a new one through the resend endpoint.
+	if err := s.issueVerification(ctx, user); err != nil {
+		logger.ErrorContext(ctx, "verification_issue_failed",
+			"user_id", user.ID(),
+			"error", err,
+		)
+	}
+
 	return user.ToResponse(), nil
 }
 
+// validateSubcategory checks that professionals can attach to the category:
+// it must be an active leaf of the category tree.
+func (s service) validateSubcategory(ctx context.Context, categoryID string) error {
+	node, err := s.categoryRepo.GetByID(ctx, categoryID)
+	if err != nil {
+		return fault.NewInternalServerError("failed to validate subcategory")
+	}
+	if node == nil || node.IsDeleted() {
+		return fault.NewBadRequest("invalid subcategory")
+	}
+
+	children, err := s.categoryRepo.CountActiveChildren(ctx, categoryID)
+	if err != nil {
+		return fault.NewInternalServerError("failed to validate subcategory")
+	}
+	if children > 0 {
+		return fault.NewBadRequest("subcategory must be a category without children")
+	}
+
+	return nil
+}
+
 func (s service) GetUserByEmail(ctx context.Context, email string) (*dto.UserResponse, error) {
 	user, err := s.userRepo.GetByEmail(ctx, email)
 	if err != nil {
@@ -154,13 +214,45 @@ func (s service) GetProfessionalUsers(ctx context.Context) ([]*dto.ProfessionalU
 	return professionals, nil
 }
 
+// avatarBucket holds the profile pictures, each under a name of its own so a
+// replaced picture can be removed once the new one is saved.
+const avatarBucket = "user-profile"
+
 func (s service) UploadUserPicture(ctx context.Context, userID string, fileHeader *multipart.FileHeader) (string, error) {
-	objectName := fmt.Sprintf("user_%s_%s", "profile", userID)
-	avatarKey, err := s.storageClient.UploadFile("user-profile", objectName, fileHeader)
+	objectName := fmt.Sprintf("user_%s_%s_%s", "profile", userID, uid.New(""))
+	avatarKey, err := s.storageClient.UploadFile(avatarBucket, objectName, fileHeader)
 	if err != nil {
 		return "", err
 	}
-	avatarURL := fmt.Sprintf("%s/%s/%s", config.GetConfig().StorageURL, "user-profile", avatarKey)
+	avatarURL := fmt.Sprintf("%s/%s/%s", config.GetConfig().StorageURL, avatarBucket, avatarKey)
 
 	return avatarURL, nil
 }
+
+// AvatarObject returns the bucket and the object behind an avatar URL built
+// by UploadUserPicture. Other URLs give ok false.
+func AvatarObject(avatarURL string) (bucket, objectName string, ok bool) {
+	prefix := fmt.Sprintf("%s/%s/", config.GetConfig().StorageURL, avatarBucket)
+	objectName, ok = strings.CutPrefix(avatarURL, prefix)
+	if !ok || objectName == "" {
+		return "", "", false
+	}
+
+	return avatarBucket, objectName, true
+}
+
+// RemoveUserPicture removes the object behind an avatar URL built by
+// UploadUserPicture. Other URLs are left alone.
+func (s service) RemoveUserPicture(ctx context.Context, avatarURL string) {
+	bucket, objectName, ok := AvatarObject(avatarURL)
+	if !ok {
+		return
+	}
+
+	if err := s.storageClient.RemoveFile(bucket, objectName); err != nil {
+		logging.FromContext(ctx).ErrorContext(ctx, "avatar_removal_failed",
+			"object", objectName,
+			"error", err,
+		)
+	}
+}
@@ -0,0 +1,132 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"msn/internal/config"
+	"msn/internal/infra/logging"
+	"msn/internal/infra/mailer"
+	"msn/internal/modules/usertoken"
+	"msn/pkg/common/fault"
+	"msn/pkg/utils/crypto"
+	"msn/pkg/utils/validation"
+	"net/url"
+	"time"
+)
+
+const verificationTokenTTL = 48 * time.Hour
+
+func (s service) VerifyEmail(ctx context.Context, token string) error {
+	logger := logging.FromContext(ctx)
+
+	if token == "" {
+		return fault.NewBadRequest("token is required")
+	}
+
+	record, err := s.userTokenRepo.GetByHash(ctx, usertoken.PurposeEmailVerification, crypto.HashToken(token))
+	if err != nil {
+		logger.ErrorContext(ctx, "db_error",
+			"operation", "userTokenRepo.GetByHash",
+			"error", err,
+		)
+		return fault.NewInternalServerError("failed to verify email")
+	}
+
+	if record == nil || !record.IsUsable() {
+		logger.DebugContext(ctx, "invalid_verification_token", "found", record != nil)
+		return fault.NewBadRequest("invalid or expired verification token")
+	}
+
+	ok, err := s.userTokenRepo.Consume(ctx, record.ID)
+	if err != nil {
+		logger.ErrorContext(ctx, "db_error",
+			"operation", "userTokenRepo.Consume",
+			"token_id", record.ID,
+			"error", err,
+		)
+		return fault.NewInternalServerError("failed to verify email")
+	}
+	if !ok {
+		logger.DebugContext(ctx, "verification_token_already_used", "token_id", record.ID)
+		return fault.NewBadRequest("invalid or expired verification token")
+	}
+
+	if err := s.userRepo.MarkVerified(ctx, record.UserID, time.Now()); err != nil {
+		logger.ErrorContext(ctx, "db_error",
+			"operation", "userRepo.MarkVerified",
+			"user_id", record.UserID,
+			"error", err,
+		)
+		return fault.NewInternalServerError("failed to verify email")
+	}
+
+	logger.InfoContext(ctx, "email_verified", "user_id", record.UserID)
+
+	return nil
+}
+
+// ResendVerification sends a fresh verification link. Like ForgotPassword it
+// answers the same way for unknown or already verified emails.
+func (s service) ResendVerification(ctx context.Context, email string) error {
+	logger := logging.FromContext(ctx)
+
+	if err := validation.ValidateEmail(email); err != nil {
+		return fault.NewBadRequest(err.Error())
+	}
+
+	user, err := s.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		logger.ErrorContext(ctx, "db_error",
+			"operation", "userRepo.GetByEmail",
+			"error", err,
+		)
+		return fault.NewInternalServerError("failed to resend verification")
+	}
+
+	if user == nil || user.IsVerified() || user.DeletedAt() != nil {
+		logger.DebugContext(ctx, "verification_resend_skipped", "email", email)
+		return nil
+	}
+
+	if err := s.issueVerification(ctx, user); err != nil {
+		logger.ErrorContext(ctx, "verification_issue_failed",
+			"user_id", user.ID(),
+			"error", err,
+		)
+		if fault.GetTag(err) == fault.MAILER_ERROR {
+			return nil
+		}
+		return fault.NewInternalServerError("failed to resend verification")
+	}
+
+	return nil
+}
+
+// issueVerification replaces any pending verification token of the user and
+// mails the new one.
+func (s service) issueVerification(ctx context.Context, user *User) error {
+	err := s.userTokenRepo.InvalidateAll(ctx, user.ID(), usertoken.PurposeEmailVerification)
+	if err != nil {
+		return err
+	}
+
+	token, plain, err := usertoken.New(user.ID(), usertoken.PurposeEmailVerification, verificationTokenTTL)
+	if err != nil {
+		return err
+	}
+
+	if err := s.userTokenRepo.Create(ctx, token); err != nil {
+		return err
+	}
+
+	msg, err := mailer.NewMessage(user.Email(), mailer.DefaultLocale, mailer.TemplateEmailVerification, mailer.EmailVerificationData{
+		Name:           user.Name(),
+		Link:           fmt.Sprintf("%s/api/v1/users/verify?token=%s", config.GetConfig().AppURL, url.QueryEscape(plain)),
+		ExpiresInHours: int(verificationTokenTTL.Hours()),
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(ctx, msg)
+}
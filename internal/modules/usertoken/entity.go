@@ -0,0 +1,117 @@
+package usertoken
+
+import (
+	"msn/internal/infra/database/models"
+	"msn/pkg/common/fault"
+	"msn/pkg/utils/crypto"
+	"msn/pkg/utils/uid"
+	"time"
+)
+
+type Purpose string
+
+const (
+	PurposePasswordReset     Purpose = "password_reset"
+	PurposeEmailVerification Purpose = "email_verification"
+	PurposeEmailChange       Purpose = "email_change"
+	// PurposeMFAPending tracks an mfa pending token, keyed by its JTI, so
+	// it opens a single session.
+	PurposeMFAPending Purpose = "mfa_pending"
+)
+
+const tokenSize = 32
+
+// Token is a single-use secret sent to a user out of band. Only its hash is
+// persisted; the plain value is returned once by New.
+type Token struct {
+	ID        string
+	UserID    string
+	Purpose   Purpose
+	TokenHash string
+	ExpiresAt time.Time
+	// SessionID is the session the token was requested from, when the
+	// request was authenticated.
+	SessionID *string
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+func New(userID string, purpose Purpose, ttl time.Duration) (*Token, string, error) {
+	plain, err := crypto.GenerateToken(tokenSize)
+	if err != nil {
+		return nil, "", fault.New(
+			"failed to generate token",
+			fault.WithTag(fault.INVALID_ENTITY),
+			fault.WithError(err),
+		)
+	}
+
+	token, err := NewWithSecret(userID, purpose, plain, ttl)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return token, plain, nil
+}
+
+// NewWithSecret is New for a secret the caller already has, such as the JTI
+// of a JWT the token tracks.
+func NewWithSecret(userID string, purpose Purpose, secret string, ttl time.Duration) (*Token, error) {
+	if userID == "" || purpose == "" || secret == "" {
+		return nil, fault.New(
+			"userID, purpose and secret are required",
+			fault.WithTag(fault.INVALID_ENTITY),
+		)
+	}
+
+	now := time.Now()
+
+	return &Token{
+		ID:        uid.New("utok"),
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: crypto.HashToken(secret),
+		ExpiresAt: now.Add(ttl),
+		UsedAt:    nil,
+		CreatedAt: now,
+	}, nil
+}
+
+func NewFromModel(m models.UserToken) *Token {
+	return &Token{
+		ID:        m.ID,
+		UserID:    m.UserID,
+		Purpose:   Purpose(m.Purpose),
+		TokenHash: m.TokenHash,
+		ExpiresAt: m.ExpiresAt,
+		SessionID: m.SessionID,
+		UsedAt:    m.UsedAt,
+		CreatedAt: m.CreatedAt,
+	}
+}
+
+func (t *Token) ToModel() models.UserToken {
+	return models.UserToken{
+		ID:        t.ID,
+		UserID:    t.UserID,
+		Purpose:   string(t.Purpose),
+		TokenHash: t.TokenHash,
+		ExpiresAt: t.ExpiresAt,
+		SessionID: t.SessionID,
+		UsedAt:    t.UsedAt,
+		CreatedAt: t.CreatedAt,
+	}
+}
+
+func (t *Token) IsExpired() bool {
+	return t.ExpiresAt.Before(time.Now())
+}
+
+func (t *Token) IsUsed() bool {
+	return t.UsedAt != nil
+}
+
+// IsUsable reports whether the token can still be redeemed.
+func (t *Token) IsUsable() bool {
+	return !t.IsUsed() && !t.IsExpired()
+}
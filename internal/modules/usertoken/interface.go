@@ -0,0 +1,12 @@
+package usertoken
+
+import "context"
+
+type Repository interface {
+	Create(ctx context.Context, token *Token) error
+	// Consume marks the token as used if it is still usable, and reports
+	// whether it was. Of concurrent callers only one gets true.
+	Consume(ctx context.Context, id string) (bool, error)
+	GetByHash(ctx context.Context, purpose Purpose, tokenHash string) (*Token, error)
+	InvalidateAll(ctx context.Context, userID string, purpose Purpose) error
+}
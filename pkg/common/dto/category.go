@@ -1,15 +1,71 @@
 package dto
 
+import "time"
+
 type Category struct {
-	ID    string        `json:"id"`
-	Name  string        `json:"name"`
-	Icon  string        `json:"icon"`
-	Users *int          `json:"users,omitempty"`
-	Subs  []Subcategory `json:"subs,omitempty"`
+	ID        string        `json:"id"`
+	ParentID  *string       `json:"parent_id,omitempty"`
+	Name      string        `json:"name"`
+	Slug      string        `json:"slug,omitempty"`
+	Icon      string        `json:"icon"`
+	Users     *int          `json:"users,omitempty"`
+	Signups   *int          `json:"signups,omitempty"`
+	Trend     []DaySignups  `json:"trend,omitempty"`
+	Subs      []Subcategory `json:"subs,omitempty"`
+	Children  []*Category   `json:"children,omitempty"`
+	Ancestors []*Category   `json:"ancestors,omitempty"`
+	DeletedAt *time.Time    `json:"deleted_at,omitempty"`
 }
 
+// Subcategory is a node as seen from its parent or from a professional
+// attached to it; CategoryID is the parent node.
 type Subcategory struct {
 	ID         string `json:"id"`
 	Name       string `json:"name"`
+	Slug       string `json:"slug,omitempty"`
 	CategoryID string `json:"category_id"`
+	Users      *int   `json:"users,omitempty"`
+}
+
+type CreateCategoryRequest struct {
+	ParentID *string `json:"parent_id,omitempty"`
+	Name     string  `json:"name"`
+	Slug     string  `json:"slug,omitempty"`
+	Icon     string  `json:"icon,omitempty"`
+}
+
+type UpdateCategoryRequest struct {
+	Name *string `json:"name,omitempty"`
+	Slug *string `json:"slug,omitempty"`
+	Icon *string `json:"icon,omitempty"`
+}
+
+type ReorderRequest struct {
+	ParentID *string  `json:"parent_id,omitempty"`
+	IDs      []string `json:"ids"`
+}
+
+type CategoryTranslation struct {
+	Locale    string     `json:"locale"`
+	Name      string     `json:"name"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+type CategoryTranslationRequest struct {
+	Name string `json:"name"`
+}
+
+// CategoryStats is the category tree with the active professionals under
+// each node (users) and how many of them signed up from From to To
+// (signups). Root nodes also carry their daily signups (trend).
+type CategoryStats struct {
+	From        string      `json:"from"`
+	To          string      `json:"to"`
+	RefreshedAt *time.Time  `json:"refreshed_at"`
+	Categories  []*Category `json:"categories"`
+}
+
+type DaySignups struct {
+	Day     string `json:"day"`
+	Signups int    `json:"signups"`
 }
@@ -0,0 +1,21 @@
+package dto
+
+type MFAEnrollResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+	QRCodePNG  string `json:"qr_code_png"`
+}
+
+type MFACodeRequest struct {
+	Code string `json:"code"`
+}
+
+type MFARecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type MFAVerifyRequest struct {
+	MFAToken    string `json:"mfa_token"`
+	Code        string `json:"code"`
+	DeviceLabel string `json:"device_label,omitempty"`
+}
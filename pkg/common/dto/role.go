@@ -1,6 +1,11 @@
 package dto
 
 type Role struct {
-	ID   string `json:"id"`
-	Name string `json:"name"`
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Permissions []string `json:"permissions,omitempty"`
+}
+
+type AssignRoleRequest struct {
+	Role string `json:"role"`
 }
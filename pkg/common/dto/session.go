@@ -3,13 +3,30 @@ package dto
 import "time"
 
 type CreateSession struct {
-	UserID string `json:"user_id"`
-	JTI    string `json:"jti"`
+	UserID string     `json:"user_id"`
+	JTI    string     `json:"jti"`
+	Device DeviceInfo `json:"device"`
+}
+
+type DeviceInfo struct {
+	Label     string `json:"label"`
+	UserAgent string `json:"user_agent"`
+	IPAddress string `json:"ip_address"`
 }
 
 type SessionResponse struct {
-	ID        string    `json:"id"`
-	Active    bool      `json:"active"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID          string    `json:"id"`
+	Active      bool      `json:"active"`
+	Current     bool      `json:"current"`
+	DeviceLabel string    `json:"device_label"`
+	UserAgent   string    `json:"user_agent"`
+	IPAddress   string    `json:"ip_address"`
+	LastSeenAt  time.Time `json:"last_seen_at"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+type RevokeSessionsResponse struct {
+	Revoked int `json:"revoked"`
 }
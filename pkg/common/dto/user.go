@@ -19,14 +19,29 @@ type CreateUser struct {
 	SubcategoryID   *string               `json:"subcategory_id,omitempty"`
 }
 
+// UpdateProfile is a partial update of the logged in user; nil fields are
+// kept and an empty SubcategoryID removes the subcategory. Version is the
+// profile version the client last read.
+type UpdateProfile struct {
+	Name          *string               `json:"name,omitempty"`
+	SubcategoryID *string               `json:"subcategory_id,omitempty"`
+	Version       *int                  `json:"version"`
+	FileHeader    *multipart.FileHeader `json:"-"`
+}
+
 type UserResponse struct {
-	ID            string    `json:"id"`
-	Name          string    `json:"name"`
-	Email         string    `json:"email"`
-	AvatarURL     string    `json:"avatar_url"`
-	RoleID        string    `json:"role_id"`
-	SubcategoryID *string   `json:"subcategory_id"`
-	CreatedAt     time.Time `json:"created_at"`
+	ID            string     `json:"id"`
+	Name          string     `json:"name"`
+	Email         string     `json:"email"`
+	AvatarURL     string     `json:"avatar_url"`
+	RoleID        string     `json:"role_id"`
+	SubcategoryID *string    `json:"subcategory_id"`
+	VerifiedAt    *time.Time `json:"verified_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+type ResendVerificationRequest struct {
+	Email string `json:"email"`
 }
 
 type UserTokenResponse struct {
@@ -39,10 +54,13 @@ type EnrichedUserResponse struct {
 	ID             string       `json:"id"`
 	Name           string       `json:"name"`
 	Email          string       `json:"email"`
+	PendingEmail   *string      `json:"pending_email,omitempty"`
 	AvatarURL      string       `json:"avatar_url"`
 	HashedPassword string       `json:"-"`
+	VerifiedAt     *time.Time   `json:"verified_at,omitempty"`
 	CreatedAt      time.Time    `json:"created_at"`
 	DeletedAt      *time.Time   `json:"deleted_at"`
+	Version        int          `json:"version"`
 	Role           *Role        `json:"role,omitempty"`
 	Subcategory    *Subcategory `json:"subcategory,omitempty"`
 	Category       *Category    `json:"category,omitempty"`
@@ -55,3 +73,11 @@ type ProfessionalUserResponse struct {
 	AvatarURL   string       `json:"avatar_url"`
 	Subcategory *Subcategory `json:"subcategory"`
 }
+
+type DataExportResponse struct {
+	ID          string     `json:"id"`
+	Status      string     `json:"status"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+}
@@ -1,15 +1,17 @@
 package fault
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 )
 
 type Fault struct {
-	HTTPCode int    `json:"-"`
-	Err      error  `json:"-"`
-	Tag      Tag    `json:"tag"`
-	Message  string `json:"message"`
+	HTTPCode int               `json:"-"`
+	Err      error             `json:"-"`
+	Headers  map[string]string `json:"-"`
+	Tag      Tag               `json:"tag"`
+	Message  string            `json:"message"`
 }
 
 // New instantiates a new Fault with the given message
@@ -55,6 +57,16 @@ func WithTag(tag Tag) func(*Fault) {
 	}
 }
 
+// WithHeader sets a response header written along with the fault
+func WithHeader(key, value string) func(*Fault) {
+	return func(f *Fault) {
+		if f.Headers == nil {
+			f.Headers = make(map[string]string)
+		}
+		f.Headers[key] = value
+	}
+}
+
 // GetHTTPCode returns the HTTP code for the fault
 func (f *Fault) GetHTTPCode() int {
 	return f.HTTPCode
@@ -67,6 +79,19 @@ func (f *Fault) Error() string {
 	return fmt.Sprintf("%s: %s", f.Tag, f.Message)
 }
 
+// Message returns the message of the innermost error wrapped by faults, such
+// as the validation error wrapped by an entity constructor.
+func Message(err error) string {
+	var f *Fault
+	for errors.As(err, &f) {
+		if f.Err == nil {
+			return f.Message
+		}
+		err = f.Err
+	}
+	return err.Error()
+}
+
 func (f *Fault) Is(target error) bool {
 	_, ok := target.(*Fault)
 	return ok
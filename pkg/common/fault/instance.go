@@ -12,6 +12,9 @@ func NewHTTPError(w http.ResponseWriter, err error) {
 	w.Header().Set("Content-Type", "application/json")
 
 	if err, ok := err.(*Fault); ok {
+		for key, value := range err.Headers {
+			w.Header().Set(key, value)
+		}
 		w.WriteHeader(err.GetHTTPCode())
 		_ = json.NewEncoder(w).Encode(err)
 		return
@@ -74,11 +77,13 @@ func NewConflict(message string) *Fault {
 	)
 }
 
-func NewTooManyRequests(message string) *Fault {
+func NewTooManyRequests(message string, options ...func(*Fault)) *Fault {
 	return New(
 		message,
-		WithHTTPCode(http.StatusTooManyRequests),
-		WithTag(TOO_MANY_REQUESTS),
+		append([]func(*Fault){
+			WithHTTPCode(http.StatusTooManyRequests),
+			WithTag(TOO_MANY_REQUESTS),
+		}, options...)...,
 	)
 }
 
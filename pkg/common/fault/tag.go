@@ -15,6 +15,7 @@ const (
 	TOO_MANY_REQUESTS     Tag = "TOO_MANY_REQUESTS_ERROR"
 	UNPROCESSABLE_ENTITY  Tag = "UNPROCESSABLE_ENTITY_ERROR"
 	DISABLED_USER         Tag = "DISABLED_USER_ERROR"
+	UNVERIFIED_USER       Tag = "UNVERIFIED_USER_ERROR"
 	DB_RESOURCE_NOT_FOUND Tag = "DB_RESOURCE_NOT_FOUND_ERROR"
 	INVALID_ENTITY        Tag = "INVALID_ENTITY_ERROR"
 	MAILER_ERROR          Tag = "MAILER_ERROR"
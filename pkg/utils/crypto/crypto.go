@@ -1,6 +1,11 @@
 package crypto
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -19,3 +24,19 @@ func PasswordMatches(password, encrypted string) bool {
 	err := bcrypt.CompareHashAndPassword([]byte(encrypted), []byte(password))
 	return err == nil
 }
+
+// GenerateToken returns a URL-safe random token built from size random bytes
+func GenerateToken(size int) (string, error) {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// HashToken returns the hex encoded SHA-256 digest of a token
+// Tokens are random, so a fast hash is enough and allows lookups by hash
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
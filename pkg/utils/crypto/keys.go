@@ -0,0 +1,78 @@
+package crypto
+
+import (
+	stdcrypto "crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+)
+
+// Signing algorithms a private key can be generated for
+const (
+	AlgorithmRS256 = "RS256"
+	AlgorithmES256 = "ES256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
+// ParsePrivateKeyPEM decodes a PKCS#8 PEM encoded private key
+// RSA, ECDSA P-256 and Ed25519 keys are accepted
+func ParsePrivateKeyPEM(data []byte) (stdcrypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("invalid PEM: no block found")
+	}
+
+	keyIfc, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse PKCS#8 key: %w", err)
+	}
+
+	switch key := keyIfc.(type) {
+	case *rsa.PrivateKey:
+		return key, nil
+	case *ecdsa.PrivateKey:
+		if key.Curve != elliptic.P256() {
+			return nil, fmt.Errorf("unsupported ECDSA curve %s, only P-256 is accepted", key.Curve.Params().Name)
+		}
+		return key, nil
+	case ed25519.PrivateKey:
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", keyIfc)
+	}
+}
+
+// GeneratePrivateKeyPEM creates a new private key for the algorithm encoded
+// as PKCS#8 PEM
+func GeneratePrivateKeyPEM(algorithm string) ([]byte, error) {
+	var (
+		key any
+		err error
+	)
+
+	switch algorithm {
+	case AlgorithmRS256:
+		key, err = rsa.GenerateKey(rand.Reader, 2048)
+	case AlgorithmES256:
+		key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case AlgorithmEdDSA:
+		_, key, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q", algorithm)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode key: %w", err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
@@ -1,11 +1,15 @@
 package httputils
 
 import (
+	"crypto/sha256"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"net/netip"
 	"net/url"
 	"strconv"
 	"strings"
@@ -29,6 +33,47 @@ func WriteJSON(w http.ResponseWriter, code int, dst any) {
 	_ = json.NewEncoder(w).Encode(dst)
 }
 
+// WriteCachedJSON writes a JSON response like WriteJSON, along with the given
+// Cache-Control and a strong ETag computed from the body. When the request's
+// If-None-Match lists that ETag it answers 304 Not Modified without a body.
+func WriteCachedJSON(w http.ResponseWriter, r *http.Request, code int, dst any, cacheControl string) {
+	body, err := json.Marshal(dst)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	body = append(body, '\n')
+
+	sum := sha256.Sum256(body)
+	etag := `"` + base64.RawURLEncoding.EncodeToString(sum[:]) + `"`
+
+	w.Header().Set("ETag", etag)
+	if cacheControl != "" {
+		w.Header().Set("Cache-Control", cacheControl)
+	}
+
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_, _ = w.Write(body)
+}
+
+// etagMatches reports whether an If-None-Match header lists etag, using the
+// weak comparison that header calls for.
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
 // ReadQueryInt reads a query string parameter from the URL values and parses it into an integer.
 // If the parameter is missing or cannot be parsed, the provided default value 'defval' is returned.
 //
@@ -155,3 +200,44 @@ func ReadRequestBody(w http.ResponseWriter, r *http.Request, dst any) error {
 
 	return nil
 }
+
+// ClientIP returns the IP address of the client that issued the request.
+// It relies on r.RemoteAddr, which middlewares.RealIP rewrites for requests
+// forwarded by the proxies in TRUSTED_PROXIES.
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// ParseTrustedProxies reads a comma separated list of IPs and CIDR ranges,
+// such as "10.0.0.0/8, 192.168.1.10".
+func ParseTrustedProxies(value string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		if strings.Contains(entry, "/") {
+			prefix, err := netip.ParsePrefix(entry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q: %w", entry, err)
+			}
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+
+		ip, err := netip.ParseAddr(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", entry, err)
+		}
+		ip = ip.Unmap()
+		prefixes = append(prefixes, netip.PrefixFrom(ip, ip.BitLen()))
+	}
+
+	return prefixes, nil
+}
@@ -0,0 +1,149 @@
+// Package jwks publishes and consumes JSON Web Key Sets (RFC 7517) so
+// services can verify tokens without holding the signing key.
+package jwks
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+type Key struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+type Set struct {
+	Keys []Key `json:"keys"`
+}
+
+// NewKey describes a signing key: RS256 for RSA, ES256 for ECDSA P-256 and
+// EdDSA for Ed25519. The key ID is the RFC 7638 thumbprint, so it is stable
+// for a given key.
+func NewKey(pub crypto.PublicKey) (Key, error) {
+	key := Key{Use: "sig"}
+
+	switch pub := pub.(type) {
+	case *rsa.PublicKey:
+		key.Kty = "RSA"
+		key.Alg = "RS256"
+		key.N = encode(pub.N.Bytes())
+		key.E = encode(big.NewInt(int64(pub.E)).Bytes())
+	case *ecdsa.PublicKey:
+		if pub.Curve != elliptic.P256() {
+			return Key{}, fmt.Errorf("unsupported ECDSA curve %s", pub.Curve.Params().Name)
+		}
+		key.Kty = "EC"
+		key.Alg = "ES256"
+		key.Crv = "P-256"
+		key.X = encode(pub.X.FillBytes(make([]byte, 32)))
+		key.Y = encode(pub.Y.FillBytes(make([]byte, 32)))
+	case ed25519.PublicKey:
+		key.Kty = "OKP"
+		key.Alg = "EdDSA"
+		key.Crv = "Ed25519"
+		key.X = encode(pub)
+	default:
+		return Key{}, fmt.Errorf("unsupported key type %T", pub)
+	}
+
+	key.Kid = key.Thumbprint()
+	return key, nil
+}
+
+// Thumbprint returns the RFC 7638 SHA-256 thumbprint of the key.
+func (k Key) Thumbprint() string {
+	// The members must be in lexicographic order with no whitespace, which
+	// is what json.Marshal produces for a map.
+	members := map[string]string{"kty": k.Kty}
+	switch k.Kty {
+	case "RSA":
+		members["n"] = k.N
+		members["e"] = k.E
+	case "EC":
+		members["crv"] = k.Crv
+		members["x"] = k.X
+		members["y"] = k.Y
+	case "OKP":
+		members["crv"] = k.Crv
+		members["x"] = k.X
+	}
+
+	b, _ := json.Marshal(members)
+	sum := sha256.Sum256(b)
+	return encode(sum[:])
+}
+
+// PublicKey decodes the key material.
+func (k Key) PublicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decode(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid modulus: %w", err)
+		}
+		e, err := decode(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exponent: %w", err)
+		}
+		exponent := new(big.Int).SetBytes(e)
+		if !exponent.IsInt64() || exponent.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid exponent")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decode(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("invalid x coordinate: %w", err)
+		}
+		y, err := decode(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("invalid y coordinate: %w", err)
+		}
+		pub := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !pub.Curve.IsOnCurve(pub.X, pub.Y) {
+			return nil, errors.New("point is not on the curve")
+		}
+		return pub, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decode(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("invalid public key: %w", err)
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid public key size")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+func encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decode(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(s)
+}
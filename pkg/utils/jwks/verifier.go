@@ -0,0 +1,229 @@
+package jwks
+
+import (
+	"context"
+	"crypto"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	DefaultCacheTTL = 10 * time.Minute
+	// DefaultIssuer is the iss claim of the tokens issued by the user
+	// service.
+	DefaultIssuer = "user-service"
+	// minRefreshInterval bounds how often an unknown kid can trigger a fetch.
+	minRefreshInterval = 10 * time.Second
+)
+
+var supportedMethods = []string{"RS256", "ES256", "EdDSA"}
+
+// Verifier checks tokens against the keys published at a JWKS URL. Keys are
+// cached and refetched when they expire or a token names an unknown kid.
+type Verifier struct {
+	url    string
+	client *http.Client
+	ttl    time.Duration
+	issuer string
+
+	mu        sync.RWMutex
+	keys      map[string]verifierKey
+	fetchedAt time.Time
+}
+
+type verifierKey struct {
+	alg string
+	pub crypto.PublicKey
+}
+
+type Option func(*Verifier)
+
+func WithHTTPClient(client *http.Client) Option {
+	return func(v *Verifier) {
+		v.client = client
+	}
+}
+
+func WithCacheTTL(ttl time.Duration) Option {
+	return func(v *Verifier) {
+		v.ttl = ttl
+	}
+}
+
+func WithIssuer(issuer string) Option {
+	return func(v *Verifier) {
+		v.issuer = issuer
+	}
+}
+
+func NewVerifier(url string, options ...Option) *Verifier {
+	v := &Verifier{
+		url:    url,
+		client: &http.Client{Timeout: 5 * time.Second},
+		ttl:    DefaultCacheTTL,
+		issuer: DefaultIssuer,
+		keys:   make(map[string]verifierKey),
+	}
+
+	for _, fn := range options {
+		fn(v)
+	}
+
+	return v
+}
+
+// Verify parses the token into claims, accepting an optional "Bearer "
+// prefix. Only unexpired access tokens from the expected issuer pass; other
+// tokens the service issues, such as mfa pending ones, are refused even when
+// signed with a published key.
+func (v *Verifier) Verify(ctx context.Context, token string, claims jwt.Claims) error {
+	token = strings.TrimPrefix(strings.TrimSpace(token), "Bearer ")
+	if token == "" {
+		return errors.New("invalid token")
+	}
+
+	keyFunc := func(t *jwt.Token) (any, error) {
+		return v.key(ctx, t)
+	}
+
+	parser := jwt.NewParser(
+		jwt.WithValidMethods(supportedMethods),
+		jwt.WithIssuer(v.issuer),
+		jwt.WithExpirationRequired(),
+	)
+
+	parsed, err := parser.ParseWithClaims(token, claims, keyFunc)
+	if err != nil {
+		return fmt.Errorf("failed to parse token: %w", err)
+	}
+
+	use, err := tokenUse(parser, parsed.Raw)
+	if err != nil {
+		return fmt.Errorf("failed to parse token: %w", err)
+	}
+	// Same rule as the issuing service: tokens from before token_use was
+	// added are access tokens.
+	if use != "" && use != "access" {
+		return fmt.Errorf("token is not an access token: %s", use)
+	}
+
+	return nil
+}
+
+// tokenUse reads the token_use claim, which the claims type of the caller
+// may not have.
+func tokenUse(parser *jwt.Parser, raw string) (string, error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return "", errors.New("token is malformed")
+	}
+
+	payload, err := parser.DecodeSegment(parts[1])
+	if err != nil {
+		return "", err
+	}
+
+	var claims struct {
+		TokenUse string `json:"token_use"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return "", err
+	}
+
+	return claims.TokenUse, nil
+}
+
+// key returns the public key named by the token kid, refusing tokens signed
+// with another algorithm than the one the key was published for.
+func (v *Verifier) key(ctx context.Context, t *jwt.Token) (crypto.PublicKey, error) {
+	kid, _ := t.Header["kid"].(string)
+	if kid == "" {
+		return nil, errors.New("token has no key id")
+	}
+
+	v.mu.RLock()
+	key, ok := v.keys[kid]
+	fetchedAt := v.fetchedAt
+	v.mu.RUnlock()
+
+	stale := time.Since(fetchedAt) > v.ttl
+	if ok && !stale {
+		return key.check(t)
+	}
+
+	if stale || time.Since(fetchedAt) > minRefreshInterval {
+		if err := v.refresh(ctx); err != nil && !ok {
+			return nil, err
+		}
+	}
+
+	v.mu.RLock()
+	key, ok = v.keys[kid]
+	v.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+
+	return key.check(t)
+}
+
+func (k verifierKey) check(t *jwt.Token) (crypto.PublicKey, error) {
+	if k.alg != "" && t.Method.Alg() != k.alg {
+		return nil, fmt.Errorf("token algorithm %s does not match key algorithm %s", t.Method.Alg(), k.alg)
+	}
+	return k.pub, nil
+}
+
+func (v *Verifier) refresh(ctx context.Context) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	// Another goroutine may have refreshed while this one waited.
+	if time.Since(v.fetchedAt) <= minRefreshInterval {
+		return nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to build jwks request: %w", err)
+	}
+
+	res, err := v.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch jwks: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to fetch jwks: unexpected status %d", res.StatusCode)
+	}
+
+	var set Set
+	if err := json.NewDecoder(res.Body).Decode(&set); err != nil {
+		return fmt.Errorf("failed to decode jwks: %w", err)
+	}
+
+	keys := make(map[string]verifierKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		pub, err := k.PublicKey()
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = verifierKey{alg: k.Alg, pub: pub}
+	}
+
+	v.keys = keys
+	v.fetchedAt = time.Now()
+
+	return nil
+}
@@ -0,0 +1,78 @@
+package jwks
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestVerifierTokenUse(t *testing.T) {
+	signer, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := NewKey(signer.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		json.NewEncoder(w).Encode(Set{Keys: []Key{key}})
+	}))
+	defer server.Close()
+
+	verifier := NewVerifier(server.URL)
+
+	tests := []struct {
+		name   string
+		claims jwt.MapClaims
+		ok     bool
+	}{
+		{"access token", jwt.MapClaims{"token_use": "access"}, true},
+		{"token without token_use", jwt.MapClaims{}, true},
+		{"mfa pending token", jwt.MapClaims{"token_use": "mfa_pending"}, false},
+		{"refresh token", jwt.MapClaims{"token_use": "refresh"}, false},
+		{"other issuer", jwt.MapClaims{"token_use": "access", "iss": "someone-else"}, false},
+		{"no expiry", jwt.MapClaims{"token_use": "access", "exp": nil}, false},
+		{"expired", jwt.MapClaims{"token_use": "access", "exp": time.Now().Add(-time.Minute).Unix()}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := jwt.MapClaims{
+				"sub": "user_1",
+				"iss": DefaultIssuer,
+				"exp": time.Now().Add(time.Minute).Unix(),
+			}
+			for k, v := range tt.claims {
+				if v == nil {
+					delete(claims, k)
+					continue
+				}
+				claims[k] = v
+			}
+
+			token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+			token.Header["kid"] = key.Kid
+			signed, err := token.SignedString(signer)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = verifier.Verify(context.Background(), "Bearer "+signed, &jwt.RegisteredClaims{})
+			if tt.ok && err != nil {
+				t.Fatalf("Verify rejected the token: %v", err)
+			}
+			if !tt.ok && err == nil {
+				t.Fatal("Verify accepted the token")
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package locale
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// Matcher picks, among the supported locales, the one that best fits what a
+// client asked for. The first supported locale is the default.
+type Matcher struct {
+	supported []string
+	matcher   language.Matcher
+}
+
+// NewMatcher builds a matcher for defaultLocale and the other supported
+// locales, written as BCP 47 tags such as pt-BR or en.
+func NewMatcher(defaultLocale string, supported ...string) (*Matcher, error) {
+	m := &Matcher{}
+	var tags []language.Tag
+
+	for _, l := range append([]string{defaultLocale}, supported...) {
+		l = strings.TrimSpace(l)
+		if l == "" {
+			continue
+		}
+
+		tag, err := language.Parse(l)
+		if err != nil {
+			return nil, fmt.Errorf("invalid locale %q: %w", l, err)
+		}
+		if _, ok := m.Lookup(tag.String()); ok {
+			continue
+		}
+
+		m.supported = append(m.supported, tag.String())
+		tags = append(tags, tag)
+	}
+	if len(tags) == 0 {
+		return nil, fmt.Errorf("a default locale is required")
+	}
+
+	m.matcher = language.NewMatcher(tags)
+	return m, nil
+}
+
+// Default returns the locale used when nothing else matches.
+func (m *Matcher) Default() string {
+	return m.supported[0]
+}
+
+// Supported returns every supported locale, the default first.
+func (m *Matcher) Supported() []string {
+	return m.supported
+}
+
+// Lookup returns the supported locale written as l, ignoring case.
+func (m *Matcher) Lookup(l string) (string, bool) {
+	for _, s := range m.supported {
+		if strings.EqualFold(s, l) {
+			return s, true
+		}
+	}
+	return "", false
+}
+
+// FromRequest returns the locale asked for in the lang query parameter or,
+// without it, in the Accept-Language header. Regional variants match their
+// language (es-MX gives es) and anything unsupported gives the default.
+func (m *Matcher) FromRequest(r *http.Request) string {
+	if lang := r.URL.Query().Get("lang"); lang != "" {
+		if tag, err := language.Parse(lang); err == nil {
+			return m.match(tag)
+		}
+		return m.Default()
+	}
+
+	tags, _, err := language.ParseAcceptLanguage(r.Header.Get("Accept-Language"))
+	if err != nil || len(tags) == 0 {
+		return m.Default()
+	}
+	return m.match(tags...)
+}
+
+func (m *Matcher) match(tags ...language.Tag) string {
+	_, i, confidence := m.matcher.Match(tags...)
+	if confidence == language.No {
+		return m.Default()
+	}
+	return m.supported[i]
+}
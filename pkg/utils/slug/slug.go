@@ -0,0 +1,42 @@
+package slug
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+var validSlug = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// Make turns s into a URL slug: accents are dropped, letters lowercased and
+// every run of other characters becomes a single hyphen.
+//
+//	slug.Make("Serviços Domésticos & Reparos") // "servicos-domesticos-reparos"
+func Make(s string) string {
+	var b strings.Builder
+	hyphen := false
+
+	for _, r := range norm.NFD.String(s) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			continue
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			if hyphen && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			hyphen = false
+			b.WriteRune(unicode.ToLower(r))
+		default:
+			hyphen = true
+		}
+	}
+
+	return b.String()
+}
+
+// Valid reports whether s is already a slug, as produced by Make.
+func Valid(s string) bool {
+	return validSlug.MatchString(s)
+}
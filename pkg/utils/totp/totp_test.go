@@ -0,0 +1,137 @@
+package totp
+
+import (
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA1 seed of RFC 6238 Appendix B, "12345678901234567890"
+// in base32.
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// rfcVectors are the SHA1 cases of RFC 6238 Appendix B. The RFC prints 8
+// digit codes; these are their last 6 digits.
+var rfcVectors = []struct {
+	unix int64
+	code string
+}{
+	{59, "287082"},
+	{1111111109, "081804"},
+	{1111111111, "050471"},
+	{1234567890, "005924"},
+	{2000000000, "279037"},
+	{20000000000, "353130"},
+}
+
+func TestCode(t *testing.T) {
+	for _, v := range rfcVectors {
+		got, err := Code(rfcSecret, Step(time.Unix(v.unix, 0)))
+		if err != nil {
+			t.Fatalf("Code at %d: %v", v.unix, err)
+		}
+		if got != v.code {
+			t.Errorf("Code at %d = %s, want %s", v.unix, got, v.code)
+		}
+	}
+}
+
+func TestCodeInvalidSecret(t *testing.T) {
+	if _, err := Code("not base32!", 1); err == nil {
+		t.Error("Code accepted an invalid secret")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, v := range rfcVectors {
+		at := time.Unix(v.unix, 0)
+		step, ok := Validate(rfcSecret, v.code, at, 0)
+		if !ok || step != Step(at) {
+			t.Errorf("Validate at %d = %d, %v, want %d, true", v.unix, step, ok, Step(at))
+		}
+	}
+}
+
+func TestValidateSkew(t *testing.T) {
+	at := time.Unix(1111111111, 0)
+	current := Step(at)
+
+	tests := []struct {
+		name   string
+		offset int64
+		skew   int64
+		ok     bool
+	}{
+		{"current step", 0, 0, true},
+		{"previous step without skew", -1, 0, false},
+		{"previous step", -1, 1, true},
+		{"next step", 1, 1, true},
+		{"two steps back", -2, 1, false},
+		{"two steps ahead", 2, 1, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, err := Code(rfcSecret, current+tt.offset)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			step, ok := Validate(rfcSecret, code, at, tt.skew)
+			if ok != tt.ok {
+				t.Fatalf("Validate = %v, want %v", ok, tt.ok)
+			}
+			// The step the code was issued for is returned, not the current
+			// one, so a caller can refuse it once it was used.
+			if ok && step != current+tt.offset {
+				t.Errorf("Validate step = %d, want %d", step, current+tt.offset)
+			}
+		})
+	}
+}
+
+func TestValidateReplay(t *testing.T) {
+	issued := time.Unix(1111111111, 0)
+	code, err := Code(rfcSecret, Step(issued))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, ok := Validate(rfcSecret, code, issued, 1)
+	if !ok {
+		t.Fatal("Validate rejected a fresh code")
+	}
+
+	// Still inside the skew window a step later, the code matches the same
+	// step again, which is what lets callers spot the replay.
+	again, ok := Validate(rfcSecret, code, issued.Add(Period*time.Second), 1)
+	if !ok || again != first {
+		t.Errorf("Validate of a replayed code = %d, %v, want %d, true", again, ok, first)
+	}
+}
+
+func TestValidateMalformed(t *testing.T) {
+	code, err := Code(rfcSecret, Step(time.Unix(59, 0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		code string
+		ok   bool
+	}{
+		{"spaced", code[:3] + " " + code[3:], true},
+		{"padded", " " + code + " ", true},
+		{"short", code[:5], false},
+		{"long", code + "0", false},
+		{"empty", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := Validate(rfcSecret, tt.code, time.Unix(59, 0), 0); ok != tt.ok {
+				t.Errorf("Validate(%q) = %v, want %v", tt.code, ok, tt.ok)
+			}
+		})
+	}
+}